@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+)
+
+// jwksClient fetches JWKS documents. Keys are only fetched when a verifier is
+// built, so a slow issuer delays the cold start by at most the timeout.
+var jwksClient = &http.Client{Timeout: 5 * time.Second}
+
+// maxJWKSSize bounds the JWKS documents read, which hold a handful of keys.
+const maxJWKSSize = 1 << 20
+
+// JWK is a JSON Web Key as published in a JWKS document. Only public RSA and
+// EC signing keys are supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read JWKS file: %w", err)
+	}
+
+	return ParseJWKS(data)
+}
+
+// FetchJWKS downloads the JWKS published by an issuer, such as
+// https://<issuer>/.well-known/jwks.json.
+func FetchJWKS(url string) (*JWKS, error) {
+	resp, err := jwksClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot fetch JWKS: %s returned %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read JWKS: %w", err)
+	}
+
+	return ParseJWKS(data)
+}
+
+func ParseJWKS(data []byte) (*JWKS, error) {
+	jwks := &JWKS{}
+	if err := json.Unmarshal(data, jwks); err != nil {
+		return nil, fmt.Errorf("cannot parse JWKS: %w", err)
+	}
+
+	for _, key := range jwks.Keys {
+		if _, err := key.PublicKey(); err != nil {
+			return nil, fmt.Errorf("invalid key %q in JWKS: %w", key.Kid, err)
+		}
+	}
+
+	return jwks, nil
+}
+
+// Key returns the key with the given id. Tokens without a key id can only be
+// verified when the set holds a single key.
+func (s *JWKS) Key(kid string) (*JWK, bool) {
+	if kid == "" && len(s.Keys) == 1 {
+		return &s.Keys[0], true
+	}
+
+	for i, key := range s.Keys {
+		if key.Kid == kid {
+			return &s.Keys[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,161 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func encodeSegment(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims Claims) string {
+	signed := encodeSegment(map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Cannot sign token: %s", err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims Claims) string {
+	signed := encodeSegment(map[string]string{"alg": "ES256", "kid": kid}) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Cannot sign token: %s", err)
+	}
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTVerifier(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	jwks := &JWKS{Keys: []JWK{
+		{
+			Kty: "RSA",
+			Kid: "rsa",
+			N:   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{
+			Kty: "EC",
+			Kid: "ec",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()),
+			Y:   base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()),
+		},
+	}}
+
+	verifier := NewJWTVerifier(jwks, "https://issuer", "products")
+	exp := float64(time.Now().Add(time.Hour).Unix())
+	claims := Claims{"sub": "alice", "iss": "https://issuer", "aud": "products", "exp": exp, "scope": "products:read products:write"}
+
+	t.Run("with a RS256 token", func(t *testing.T) {
+		verified, err := verifier.Verify(signRS256(t, rsaKey, "rsa", claims))
+		if err != nil {
+			t.Fatalf("Got unexpected error: %s", err)
+		}
+
+		if verified.Subject() != "alice" || !reflect.DeepEqual(verified.Scopes(), []string{"products:read", "products:write"}) {
+			t.Errorf("Got unexpected claims %v", verified)
+		}
+	})
+
+	t.Run("with a ES256 token", func(t *testing.T) {
+		if _, err := verifier.Verify(signES256(t, ecKey, "ec", claims)); err != nil {
+			t.Fatalf("Got unexpected error: %s", err)
+		}
+	})
+
+	invalid := map[string]string{
+		"wrong key":      signRS256(t, otherKey, "rsa", claims),
+		"unknown key id": signRS256(t, rsaKey, "other", claims),
+		"wrong issuer":   signRS256(t, rsaKey, "rsa", Claims{"iss": "https://other", "aud": "products", "exp": exp}),
+		"wrong audience": signRS256(t, rsaKey, "rsa", Claims{"iss": "https://issuer", "aud": "other", "exp": exp}),
+		"missing exp":    signRS256(t, rsaKey, "rsa", Claims{"iss": "https://issuer", "aud": "products"}),
+		"malformed":      "not-a-token",
+	}
+
+	for name, token := range invalid {
+		t.Run("with "+name, func(t *testing.T) {
+			if _, err := verifier.Verify(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Got error %v, expected ErrInvalidToken", err)
+			}
+		})
+	}
+
+	t.Run("with an expired token", func(t *testing.T) {
+		expired := Claims{"iss": "https://issuer", "aud": "products", "exp": float64(time.Now().Add(-time.Hour).Unix())}
+		if _, err := verifier.Verify(signRS256(t, rsaKey, "rsa", expired)); !errors.Is(err, ErrTokenExpired) {
+			t.Errorf("Got error %v, expected ErrTokenExpired", err)
+		}
+	})
+}
+
+func TestNewJWTVerifierFromEnvFetchesJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := JWKS{Keys: []JWK{{
+		Kty: "RSA",
+		Kid: "rsa",
+		N:   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/jwks.json" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer server.Close()
+
+	t.Setenv("JWKS_FILE", "")
+	t.Setenv("JWKS_URL", server.URL+"/.well-known/jwks.json")
+	t.Setenv("JWT_ISSUER", "https://issuer")
+	t.Setenv("JWT_AUDIENCE", "")
+
+	verifier, err := NewJWTVerifierFromEnv()
+	if err != nil || verifier == nil {
+		t.Fatalf("Got verifier %v and error %v, expected a verifier", verifier, err)
+	}
+
+	exp := float64(time.Now().Add(time.Hour).Unix())
+	if _, err := verifier.Verify(signRS256(t, rsaKey, "rsa", Claims{"iss": "https://issuer", "exp": exp})); err != nil {
+		t.Errorf("Got unexpected error: %s", err)
+	}
+
+	t.Setenv("JWKS_URL", server.URL+"/missing")
+	if _, err := NewJWTVerifierFromEnv(); err == nil {
+		t.Errorf("Expected an error when the JWKS can't be fetched")
+	}
+}
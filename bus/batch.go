@@ -0,0 +1,149 @@
+package bus
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// FailureCodeEntryTooLarge reports events too large to ever be accepted.
+const FailureCodeEntryTooLarge = "EntryTooLarge"
+
+// laneEvent is an event along with its position in the events given to Put.
+type laneEvent struct {
+	index int
+	event types.Event
+}
+
+type laneFailure struct {
+	index int
+	types.FailedEvent
+}
+
+// putLanes splits the events into n lanes that putLane sends concurrently,
+// and returns the failed events in the order of events.
+func putLanes(events []types.Event, n int, putLane func([]laneEvent) ([]laneFailure, error)) ([]types.FailedEvent, error) {
+	lanes := laneEvents(events, n)
+
+	laneFailures := make([][]laneFailure, len(lanes))
+	laneErrs := make([]error, len(lanes))
+
+	var wg sync.WaitGroup
+	for i, lane := range lanes {
+		if len(lane) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, lane []laneEvent) {
+			defer wg.Done()
+
+			laneFailures[i], laneErrs[i] = putLane(lane)
+		}(i, lane)
+	}
+	wg.Wait()
+
+	failures := []laneFailure{}
+	for _, f := range laneFailures {
+		failures = append(failures, f...)
+	}
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].index < failures[j].index
+	})
+
+	failedEvents := make([]types.FailedEvent, len(failures))
+	for i, failure := range failures {
+		failedEvents[i] = failure.FailedEvent
+	}
+
+	for _, err := range laneErrs {
+		if err != nil {
+			return failedEvents, err
+		}
+	}
+
+	return failedEvents, nil
+}
+
+// laneEvents splits the events into n lanes, keeping their order. Events with
+// the same subject always share a lane, while those without one have no order
+// to keep and are spread evenly.
+func laneEvents(events []types.Event, n int) [][]laneEvent {
+	if n < 1 {
+		n = 1
+	}
+
+	lanes := make([][]laneEvent, n)
+	for i, event := range events {
+		lane := i % n
+		if event.Subject != "" {
+			h := fnv.New32a()
+			h.Write([]byte(event.Subject))
+			lane = int(h.Sum32() % uint32(n))
+		}
+
+		lanes[lane] = append(lanes[lane], laneEvent{index: i, event: event})
+	}
+
+	return lanes
+}
+
+// batchEvents calls batchFn with consecutive events, at most maxBatchSize at a
+// time and maxRequestSize bytes as measured by sizeFn. An event that is larger
+// than maxEntrySize is never sent, and is returned as failed with the
+// FailureCodeEntryTooLarge code instead.
+func batchEvents(events []laneEvent, maxBatchSize int, maxRequestSize int, maxEntrySize int, sizeFn func(types.Event) int, batchFn func([]laneEvent) ([]laneFailure, error)) ([]laneFailure, error) {
+	batchFailures := []laneFailure{}
+
+	batch := []laneEvent{}
+	batchSize := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		failures, err := batchFn(batch)
+		if err != nil {
+			return err
+		}
+
+		batchFailures = append(batchFailures, failures...)
+		batch = []laneEvent{}
+		batchSize = 0
+
+		return nil
+	}
+
+	for _, event := range events {
+		size := sizeFn(event.event)
+		if size > maxEntrySize {
+			batchFailures = append(batchFailures, laneFailure{
+				index: event.index,
+				FailedEvent: types.FailedEvent{
+					Event:          event.event,
+					FailureCode:    FailureCodeEntryTooLarge,
+					FailureMessage: fmt.Sprintf("entry is %d bytes, the limit is %d", size, maxEntrySize),
+				},
+			})
+			continue
+		}
+
+		if len(batch) == maxBatchSize || batchSize+size > maxRequestSize {
+			if err := flush(); err != nil {
+				return batchFailures, err
+			}
+		}
+
+		batch = append(batch, event)
+		batchSize += size
+	}
+
+	if err := flush(); err != nil {
+		return batchFailures, err
+	}
+
+	return batchFailures, nil
+}
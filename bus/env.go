@@ -0,0 +1,63 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// NewBusFromEnv returns the bus named by BUS_TYPE: eventbridge, the default,
+// sns, sqs, kinesis or file. Each needs its own variable naming the target:
+// EVENT_BUS_NAME, SNS_TOPIC_ARN, SQS_QUEUE_URL, KINESIS_STREAM_NAME or
+// EVENT_FILE.
+func NewBusFromEnv(ctx context.Context) (types.Bus, error) {
+	switch busType := os.Getenv("BUS_TYPE"); busType {
+	case "", "eventbridge":
+		name, err := getenv("EVENT_BUS_NAME")
+		if err != nil {
+			return nil, err
+		}
+		return NewEventBridgeBus(ctx, name), nil
+	case "sns":
+		topicArn, err := getenv("SNS_TOPIC_ARN")
+		if err != nil {
+			return nil, err
+		}
+		return NewSNSBus(ctx, topicArn), nil
+	case "sqs":
+		queueUrl, err := getenv("SQS_QUEUE_URL")
+		if err != nil {
+			return nil, err
+		}
+		return NewSQSBus(ctx, queueUrl), nil
+	case "kinesis":
+		streamName, err := getenv("KINESIS_STREAM_NAME")
+		if err != nil {
+			return nil, err
+		}
+		return NewKinesisBus(ctx, streamName), nil
+	case "file":
+		path, err := getenv("EVENT_FILE")
+		if err != nil {
+			return nil, err
+		}
+		fileBus, err := NewFileBus(path)
+		if err != nil {
+			return nil, err
+		}
+		return fileBus, nil
+	default:
+		return nil, fmt.Errorf("unknown BUS_TYPE '%s'", busType)
+	}
+}
+
+func getenv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("need %s environment variable", name)
+	}
+
+	return value, nil
+}
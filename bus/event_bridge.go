@@ -3,7 +3,7 @@ package bus
 import (
 	"context"
 	"log"
-	"math"
+	"time"
 
 	"github.com/aws-samples/serverless-go-demo/types"
 
@@ -13,14 +13,50 @@ import (
 	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchevents/types"
 )
 
+const (
+	// PutEvents limits, see
+	// https://docs.aws.amazon.com/eventbridge/latest/userguide/eb-putevent-size.html
+	maxEntriesPerRequest = 10
+	maxRequestSize       = 256 * 1024
+
+	// DefaultConcurrency is how many PutEvents requests are in flight at once.
+	DefaultConcurrency = 8
+)
+
+// eventBridgeClient is the part of the EventBridge client used by the bus.
+type eventBridgeClient interface {
+	PutEvents(context.Context, *cloudwatchevents.PutEventsInput, ...func(*cloudwatchevents.Options)) (*cloudwatchevents.PutEventsOutput, error)
+}
+
 type EventBridgeBus struct {
-	client  *cloudwatchevents.Client
-	busName string
+	client      eventBridgeClient
+	busName     string
+	retry       RetryPolicy
+	concurrency int
+	sleep       func(context.Context, time.Duration) error
 }
 
 var _ types.Bus = (*EventBridgeBus)(nil)
 
-func NewEventBridgeBus(ctx context.Context, busName string) *EventBridgeBus {
+type EventBridgeBusOption func(*EventBridgeBus)
+
+// WithRetryPolicy sets how entries rejected with a retryable code are resent.
+// Requests failing as a whole are already retried by the SDK.
+func WithRetryPolicy(p RetryPolicy) EventBridgeBusOption {
+	return func(e *EventBridgeBus) {
+		e.retry = p
+	}
+}
+
+// WithConcurrency sets how many requests are sent at once. Events about the
+// same product are still sent one request after the other, in order.
+func WithConcurrency(n int) EventBridgeBusOption {
+	return func(e *EventBridgeBus) {
+		e.concurrency = n
+	}
+}
+
+func NewEventBridgeBus(ctx context.Context, busName string, opts ...EventBridgeBusOption) *EventBridgeBus {
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
 		log.Fatalf("unable to load SDK config, %v", err)
@@ -28,87 +64,127 @@ func NewEventBridgeBus(ctx context.Context, busName string) *EventBridgeBus {
 
 	client := cloudwatchevents.NewFromConfig(cfg)
 
-	return &EventBridgeBus{
-		client:  client,
-		busName: busName,
+	e := &EventBridgeBus{
+		client:      client,
+		busName:     busName,
+		retry:       DefaultRetryPolicy,
+		concurrency: DefaultConcurrency,
+		sleep:       sleep,
+	}
+
+	for _, opt := range opts {
+		opt(e)
 	}
+
+	return e
 }
 
+// Put spreads the events over lanes by subject, the product id, and sends the
+// lanes concurrently. Each lane is sent one batch after the other, so events
+// about a product are put in the order they are given. Failed events are
+// returned in that order too, whatever the lane that sent them.
 func (e *EventBridgeBus) Put(ctx context.Context, events []types.Event) ([]types.FailedEvent, error) {
-	failedBatchEvents, err :=
-		batchEvents(events, 10, func(batchEvents []types.Event) ([]types.FailedEvent, error) {
-			eventBridgeEvents := make([]cloudwatchtypes.PutEventsRequestEntry, len(batchEvents))
-
-			for i, event := range batchEvents {
-				eventBridgeEvent := cloudwatchtypes.PutEventsRequestEntry{
-					EventBusName: &e.busName,
-					Source:       aws.String(event.Source),
-					Detail:       aws.String(event.Detail),
-					DetailType:   aws.String(event.DetailType),
-					Resources:    event.Resources,
-				}
-
-				eventBridgeEvents[i] = eventBridgeEvent
-			}
+	return putLanes(events, e.concurrency, func(lane []laneEvent) ([]laneFailure, error) {
+		return batchEvents(lane, maxEntriesPerRequest, maxRequestSize, maxRequestSize, entrySize, func(batchEvents []laneEvent) ([]laneFailure, error) {
+			return e.putWithRetries(ctx, batchEvents)
+		})
+	})
+}
 
-			result, err := e.client.PutEvents(ctx, &cloudwatchevents.PutEventsInput{
-				Entries: eventBridgeEvents,
-			})
+// putWithRetries sends the events and then resends those that failed with a
+// retryable code, following the retry policy. It returns the events that
+// failed permanently or still failed after the last attempt.
+func (e *EventBridgeBus) putWithRetries(ctx context.Context, events []laneEvent) ([]laneFailure, error) {
+	failures, err := e.putEntries(ctx, events)
+	if err != nil {
+		return failures, err
+	}
 
-			failedEvents := []types.FailedEvent{}
-			if err != nil {
-				return failedEvents, err
+	for attempt := 2; attempt <= e.retry.MaxAttempts; attempt++ {
+		permanent := []laneFailure{}
+		retryable := []laneEvent{}
+		for _, failure := range failures {
+			if types.IsRetryable(failure.FailureCode) {
+				retryable = append(retryable, laneEvent{index: failure.index, event: failure.Event})
+			} else {
+				permanent = append(permanent, failure)
 			}
+		}
 
-			if result.FailedEntryCount > 0 {
-				for i, entry := range result.Entries {
-					if entry.EventId != nil {
-						continue
-					}
+		if len(retryable) == 0 {
+			break
+		}
 
-					failedEvent := types.FailedEvent{
-						Event:          batchEvents[i],
-						FailureCode:    *entry.ErrorCode,
-						FailureMessage: *entry.ErrorMessage,
-					}
+		delay := e.retry.backoff(attempt - 1)
+		if !canWait(ctx, delay) || e.sleep(ctx, delay) != nil {
+			break
+		}
 
-					failedEvents = append(failedEvents, failedEvent)
-				}
-			}
+		retriedFailures, err := e.putEntries(ctx, retryable)
+		if err != nil {
+			// The entries keep the failure of their previous attempt.
+			log.Printf("failed to resend %d events: %v", len(retryable), err)
+			break
+		}
 
-			return failedEvents, nil
-		})
+		failures = append(permanent, retriedFailures...)
+	}
 
-	return failedBatchEvents, err
+	return failures, nil
 }
 
-func batchEvents(events []types.Event, maxBatchSize uint, batchFn func([]types.Event) ([]types.FailedEvent, error)) ([]types.FailedEvent, error) {
-	skip := 0
-	recordsAmount := len(events)
-	batchAmount := int(math.Ceil(float64(recordsAmount) / float64(maxBatchSize)))
+func (e *EventBridgeBus) putEntries(ctx context.Context, batchEvents []laneEvent) ([]laneFailure, error) {
+	eventBridgeEvents := make([]cloudwatchtypes.PutEventsRequestEntry, len(batchEvents))
+
+	for i, batchEvent := range batchEvents {
+		event := batchEvent.event
+		eventBridgeEvent := cloudwatchtypes.PutEventsRequestEntry{
+			EventBusName: &e.busName,
+			Source:       aws.String(event.Source),
+			Detail:       aws.String(event.Detail),
+			DetailType:   aws.String(event.DetailType),
+			Resources:    event.Resources,
+		}
 
-	batchFailedEvents := []types.FailedEvent{}
+		eventBridgeEvents[i] = eventBridgeEvent
+	}
 
-	for i := 0; i < batchAmount; i++ {
-		lowerBound := skip
-		upperBound := skip + int(maxBatchSize)
+	result, err := e.client.PutEvents(ctx, &cloudwatchevents.PutEventsInput{
+		Entries: eventBridgeEvents,
+	})
 
-		if upperBound > recordsAmount {
-			upperBound = recordsAmount
-		}
+	failures := []laneFailure{}
+	if err != nil {
+		return failures, err
+	}
 
-		batchEvents := events[lowerBound:upperBound]
-		skip += int(maxBatchSize)
+	if result.FailedEntryCount > 0 {
+		for i, entry := range result.Entries {
+			if entry.EventId != nil {
+				continue
+			}
 
-		failedEvents, err := batchFn(batchEvents)
-		if err != nil {
-			return batchFailedEvents, err
+			failures = append(failures, laneFailure{
+				index: batchEvents[i].index,
+				FailedEvent: types.FailedEvent{
+					Event:          batchEvents[i].event,
+					FailureCode:    aws.ToString(entry.ErrorCode),
+					FailureMessage: aws.ToString(entry.ErrorMessage),
+				},
+			})
 		}
+	}
 
-		if len(failedEvents) > 0 {
-			batchFailedEvents = append(batchFailedEvents, failedEvents...)
-		}
+	return failures, nil
+}
+
+// entrySize computes the size of an entry the way EventBridge counts it
+// against the request size limit.
+func entrySize(event types.Event) int {
+	size := len(event.Source) + len(event.DetailType) + len(event.Detail)
+	for _, resource := range event.Resources {
+		size += len(resource)
 	}
 
-	return batchFailedEvents, nil
+	return size
 }
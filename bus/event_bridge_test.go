@@ -0,0 +1,262 @@
+//go:build unit
+// +build unit
+
+package bus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchevents"
+	cloudwatchtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchevents/types"
+)
+
+// fakeEventBridge fails the entries whose detail is in failures with the
+// given codes, a code being used once per call. Each call takes latency.
+type fakeEventBridge struct {
+	failures map[string][]string
+	calls    [][]string
+	latency  time.Duration
+	mu       sync.Mutex
+}
+
+func (f *fakeEventBridge) PutEvents(ctx context.Context, input *cloudwatchevents.PutEventsInput, opts ...func(*cloudwatchevents.Options)) (*cloudwatchevents.PutEventsOutput, error) {
+	time.Sleep(f.latency)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	output := &cloudwatchevents.PutEventsOutput{}
+	details := []string{}
+
+	for i, entry := range input.Entries {
+		detail := aws.ToString(entry.Detail)
+		details = append(details, detail)
+
+		if codes := f.failures[detail]; len(codes) > 0 {
+			f.failures[detail] = codes[1:]
+			output.FailedEntryCount++
+			output.Entries = append(output.Entries, cloudwatchtypes.PutEventsResultEntry{
+				ErrorCode:    aws.String(codes[0]),
+				ErrorMessage: aws.String(codes[0]),
+			})
+			continue
+		}
+
+		output.Entries = append(output.Entries, cloudwatchtypes.PutEventsResultEntry{EventId: aws.String(fmt.Sprint(i))})
+	}
+
+	f.calls = append(f.calls, details)
+
+	return output, nil
+}
+
+func testBus(client eventBridgeClient, policy RetryPolicy) *EventBridgeBus {
+	return &EventBridgeBus{
+		client:  client,
+		busName: "test",
+		retry:   policy,
+		sleep:   func(context.Context, time.Duration) error { return nil },
+	}
+}
+
+func testEvents(details ...string) []types.Event {
+	events := make([]types.Event, len(details))
+	for i, detail := range details {
+		events[i] = types.Event{Detail: detail, DetailType: "Test", Source: "test"}
+	}
+
+	return events
+}
+
+func TestPutRetriesOnlyRetryableFailures(t *testing.T) {
+	client := &fakeEventBridge{failures: map[string][]string{
+		"throttled": {"ThrottlingException", "InternalFailure"},
+		"denied":    {"AccessDeniedException"},
+	}}
+
+	failedEvents, err := testBus(client, DefaultRetryPolicy).Put(context.Background(), testEvents("ok", "throttled", "denied"))
+	if err != nil {
+		t.Fatalf("Put returned an error: %s", err)
+	}
+
+	if len(failedEvents) != 1 || failedEvents[0].Detail != "denied" || failedEvents[0].FailureCode != "AccessDeniedException" {
+		t.Errorf("Got failed events %+v, expected only the denied one", failedEvents)
+	}
+
+	if len(client.calls) != 3 || len(client.calls[1]) != 1 || client.calls[1][0] != "throttled" {
+		t.Errorf("Got calls %v, expected the throttled event to be resent alone twice", client.calls)
+	}
+}
+
+func TestPutStopsAfterMaxAttempts(t *testing.T) {
+	client := &fakeEventBridge{failures: map[string][]string{
+		"throttled": {"ThrottlingException", "ThrottlingException", "ThrottlingException"},
+	}}
+
+	failedEvents, _ := testBus(client, RetryPolicy{MaxAttempts: 2}).Put(context.Background(), testEvents("throttled"))
+	if len(failedEvents) != 1 || len(client.calls) != 2 {
+		t.Errorf("Got failed events %+v after %d calls, expected 1 after 2", failedEvents, len(client.calls))
+	}
+}
+
+func TestPutStopsRetryingBeforeDeadline(t *testing.T) {
+	client := &fakeEventBridge{failures: map[string][]string{
+		"throttled": {"ThrottlingException"},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadlineMargin)
+	defer cancel()
+
+	failedEvents, _ := testBus(client, DefaultRetryPolicy).Put(ctx, testEvents("throttled"))
+	if len(failedEvents) != 1 || len(client.calls) != 1 {
+		t.Errorf("Got failed events %+v after %d calls, expected no retry this close to the deadline", failedEvents, len(client.calls))
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for retry := 1; retry < 10; retry++ {
+		if delay := policy.backoff(retry); delay < 0 || delay > time.Second {
+			t.Errorf("Got delay %s for retry %d", delay, retry)
+		}
+	}
+}
+
+func TestPutBatchesBySize(t *testing.T) {
+	client := &fakeEventBridge{}
+	large := strings.Repeat("x", 100*1024)
+	tooLarge := strings.Repeat("x", maxRequestSize)
+
+	failedEvents, err := testBus(client, DefaultRetryPolicy).Put(context.Background(), testEvents(large, large, tooLarge, large, "small"))
+	if err != nil {
+		t.Fatalf("Put returned an error: %s", err)
+	}
+
+	if len(failedEvents) != 1 || failedEvents[0].FailureCode != FailureCodeEntryTooLarge {
+		t.Errorf("Got failed events %+v, expected the oversized event only", failedEvents)
+	}
+
+	sizes := []int{}
+	for _, call := range client.calls {
+		sizes = append(sizes, len(call))
+	}
+	if !reflect.DeepEqual(sizes, []int{2, 2}) {
+		t.Errorf("Got batches of %v events, expected [2 2]", sizes)
+	}
+}
+
+func TestPutBatchesByCount(t *testing.T) {
+	client := &fakeEventBridge{}
+	details := make([]string, 25)
+	for i := range details {
+		details[i] = fmt.Sprint(i)
+	}
+
+	testBus(client, DefaultRetryPolicy).Put(context.Background(), testEvents(details...))
+
+	if len(client.calls) != 3 || len(client.calls[0]) != maxEntriesPerRequest || len(client.calls[2]) != 5 {
+		t.Errorf("Got calls %v, expected batches of 10, 10 and 5", client.calls)
+	}
+}
+
+func TestPutKeepsProductOrder(t *testing.T) {
+	client := &fakeEventBridge{}
+	bus := testBus(client, DefaultRetryPolicy)
+	bus.concurrency = 4
+
+	events := []types.Event{}
+	for i := 0; i < 30; i++ {
+		events = append(events, types.Event{Subject: fmt.Sprint("product-", i%3), Detail: fmt.Sprint(i%3, "-", i)})
+	}
+
+	if _, err := bus.Put(context.Background(), events); err != nil {
+		t.Fatalf("Put returned an error: %s", err)
+	}
+
+	sent := map[string][]string{}
+	for _, call := range client.calls {
+		for _, detail := range call {
+			product := detail[:1]
+			sent[product] = append(sent[product], detail)
+		}
+	}
+
+	for _, event := range events {
+		product := event.Detail[:1]
+		if len(sent[product]) == 0 || sent[product][0] != event.Detail {
+			t.Fatalf("Got events %v for product %s, expected %s next", sent[product], product, event.Detail)
+		}
+		sent[product] = sent[product][1:]
+	}
+}
+
+func TestPutReturnsFailuresInOrder(t *testing.T) {
+	client := &fakeEventBridge{failures: map[string][]string{}}
+	bus := testBus(client, RetryPolicy{MaxAttempts: 1})
+	bus.concurrency = 4
+
+	events := []types.Event{}
+	for i := 0; i < 40; i++ {
+		detail := fmt.Sprint(i)
+		events = append(events, types.Event{Subject: fmt.Sprint("product-", i%7), Detail: detail})
+		if i%3 == 0 {
+			client.failures[detail] = []string{"AccessDeniedException"}
+		}
+	}
+
+	failedEvents, err := bus.Put(context.Background(), events)
+	if err != nil {
+		t.Fatalf("Put returned an error: %s", err)
+	}
+
+	details := []string{}
+	for _, failedEvent := range failedEvents {
+		details = append(details, failedEvent.Detail)
+	}
+
+	expected := []string{}
+	for i := 0; i < 40; i += 3 {
+		expected = append(expected, fmt.Sprint(i))
+	}
+
+	if !reflect.DeepEqual(details, expected) {
+		t.Errorf("Got failed events %v, expected %v", details, expected)
+	}
+}
+
+// benchmarkPut puts a stream batch of 1000 events about 100 products, with
+// requests taking a few milliseconds like they do against EventBridge.
+func benchmarkPut(b *testing.B, concurrency int) {
+	events := make([]types.Event, 1000)
+	for i := range events {
+		events[i] = types.Event{Subject: fmt.Sprint("product-", i%100), Detail: fmt.Sprint(i), DetailType: "Test", Source: "test"}
+	}
+
+	bus := testBus(&fakeEventBridge{latency: 2 * time.Millisecond}, DefaultRetryPolicy)
+	bus.concurrency = concurrency
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := bus.Put(context.Background(), events); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPutSequential(b *testing.B) {
+	benchmarkPut(b, 1)
+}
+
+func BenchmarkPutConcurrent(b *testing.B) {
+	benchmarkPut(b, DefaultConcurrency)
+}
@@ -0,0 +1,91 @@
+package bus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// FileBus appends the events put on it to a file as newline-delimited JSON, so
+// that they can be followed with `tail -f` during local runs.
+type FileBus struct {
+	mu   sync.Mutex
+	file *os.File
+	now  func() time.Time
+}
+
+var _ types.Bus = (*FileBus)(nil)
+
+// fileEvent is a line of the file. The detail is written as JSON when it is
+// JSON, which it is for product events, so that tools like jq can read it.
+type fileEvent struct {
+	Time       time.Time       `json:"time"`
+	Id         string          `json:"id,omitempty"`
+	Source     string          `json:"source"`
+	DetailType string          `json:"detailType"`
+	Subject    string          `json:"subject,omitempty"`
+	Resources  []string        `json:"resources,omitempty"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+// NewFileBus opens the file at path for appending, creating it if needed.
+func NewFileBus(path string) (*FileBus, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open event file: %w", err)
+	}
+
+	return &FileBus{
+		file: file,
+		now:  time.Now,
+	}, nil
+}
+
+// Put writes all the events at once, so that lines from concurrent processes
+// don't interleave. They all fail together when the write fails.
+func (f *FileBus) Put(ctx context.Context, events []types.Event) ([]types.FailedEvent, error) {
+	var buf bytes.Buffer
+	now := f.now().UTC()
+
+	for _, event := range events {
+		detail := json.RawMessage(event.Detail)
+		if !json.Valid(detail) {
+			detail, _ = json.Marshal(event.Detail)
+		}
+
+		line, err := json.Marshal(fileEvent{
+			Time:       now,
+			Id:         event.Id,
+			Source:     event.Source,
+			DetailType: event.DetailType,
+			Subject:    event.Subject,
+			Resources:  event.Resources,
+			Detail:     detail,
+		})
+		if err != nil {
+			return []types.FailedEvent{}, fmt.Errorf("unable to marshal event: %w", err)
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.file.Write(buf.Bytes()); err != nil {
+		return []types.FailedEvent{}, fmt.Errorf("unable to write events: %w", err)
+	}
+
+	return []types.FailedEvent{}, nil
+}
+
+func (f *FileBus) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,54 @@
+//go:build unit
+// +build unit
+
+package bus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileBusAppendsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	for _, details := range [][]string{{`{"id":"1"}`}, {`{"id":"2"}`, "not json"}} {
+		bus, err := NewFileBus(path)
+		if err != nil {
+			t.Fatalf("NewFileBus returned an error: %s", err)
+		}
+		if _, err := bus.Put(context.Background(), testEvents(details...)); err != nil {
+			t.Fatalf("Put returned an error: %s", err)
+		}
+		bus.Close()
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	details := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := fileEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Invalid line %s: %s", scanner.Text(), err)
+		}
+		details = append(details, string(line.Detail))
+	}
+
+	expected := []string{`{"id":"1"}`, `{"id":"2"}`, `"not json"`}
+	if len(details) != len(expected) {
+		t.Fatalf("Got details %v, expected %v", details, expected)
+	}
+	for i := range expected {
+		if details[i] != expected[i] {
+			t.Errorf("Got detail %s, expected %s", details[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package bus
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+const (
+	// PutRecords limits, see
+	// https://docs.aws.amazon.com/kinesis/latest/APIReference/API_PutRecords.html
+	maxKinesisRecordsPerRequest = 500
+	maxKinesisRequestSize       = 5 * 1024 * 1024
+	maxKinesisRecordSize        = 1024 * 1024
+)
+
+// kinesisClient is the part of the Kinesis client used by the bus.
+type kinesisClient interface {
+	PutRecords(context.Context, *kinesis.PutRecordsInput, ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error)
+}
+
+// KinesisBus puts the detail of events as records of a Kinesis data stream.
+// Records are partitioned by product, so that the changes of a product land
+// on the same shard, in order. The detail type is only known from the detail.
+type KinesisBus struct {
+	client     kinesisClient
+	streamName string
+}
+
+var _ types.Bus = (*KinesisBus)(nil)
+
+func NewKinesisBus(ctx context.Context, streamName string) *KinesisBus {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	return &KinesisBus{
+		client:     kinesis.NewFromConfig(cfg),
+		streamName: streamName,
+	}
+}
+
+func (k *KinesisBus) Put(ctx context.Context, events []types.Event) ([]types.FailedEvent, error) {
+	return putLanes(events, DefaultConcurrency, func(lane []laneEvent) ([]laneFailure, error) {
+		return batchEvents(lane, maxKinesisRecordsPerRequest, maxKinesisRequestSize, maxKinesisRecordSize, recordSize, func(batchEvents []laneEvent) ([]laneFailure, error) {
+			return k.putRecords(ctx, batchEvents)
+		})
+	})
+}
+
+func (k *KinesisBus) putRecords(ctx context.Context, batchEvents []laneEvent) ([]laneFailure, error) {
+	records := make([]kinesistypes.PutRecordsRequestEntry, len(batchEvents))
+
+	for i, batchEvent := range batchEvents {
+		records[i] = kinesistypes.PutRecordsRequestEntry{
+			Data:         []byte(batchEvent.event.Detail),
+			PartitionKey: aws.String(partitionKey(batchEvent.event)),
+		}
+	}
+
+	result, err := k.client.PutRecords(ctx, &kinesis.PutRecordsInput{
+		StreamName: &k.streamName,
+		Records:    records,
+	})
+	if err != nil {
+		return []laneFailure{}, err
+	}
+
+	failures := []laneFailure{}
+	for i, record := range result.Records {
+		if record.ErrorCode == nil {
+			continue
+		}
+
+		failures = append(failures, laneFailure{
+			index: batchEvents[i].index,
+			FailedEvent: types.FailedEvent{
+				Event:          batchEvents[i].event,
+				FailureCode:    aws.ToString(record.ErrorCode),
+				FailureMessage: aws.ToString(record.ErrorMessage),
+			},
+		})
+	}
+
+	return failures, nil
+}
+
+// recordSize computes the size of a record the way Kinesis counts it against
+// the record and request size limits.
+func recordSize(event types.Event) int {
+	return len(event.Detail) + len(partitionKey(event))
+}
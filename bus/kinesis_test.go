@@ -0,0 +1,73 @@
+//go:build unit
+// +build unit
+
+package bus
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+// fakeKinesis fails the records whose data is in failures with the given code.
+type fakeKinesis struct {
+	failures map[string]string
+	records  []kinesistypes.PutRecordsRequestEntry
+	mu       sync.Mutex
+}
+
+func (f *fakeKinesis) PutRecords(ctx context.Context, input *kinesis.PutRecordsInput, opts ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	output := &kinesis.PutRecordsOutput{FailedRecordCount: aws.Int32(0)}
+	for _, record := range input.Records {
+		f.records = append(f.records, record)
+
+		if code, ok := f.failures[string(record.Data)]; ok {
+			*output.FailedRecordCount++
+			output.Records = append(output.Records, kinesistypes.PutRecordsResultEntry{ErrorCode: aws.String(code)})
+			continue
+		}
+
+		output.Records = append(output.Records, kinesistypes.PutRecordsResultEntry{SequenceNumber: aws.String("1"), ShardId: aws.String("shard")})
+	}
+
+	return output, nil
+}
+
+func TestKinesisBusPartitionsByProduct(t *testing.T) {
+	client := &fakeKinesis{failures: map[string]string{"b": "ProvisionedThroughputExceededException"}}
+	tooLarge := strings.Repeat("x", maxKinesisRecordSize)
+	events := []types.Event{
+		{Subject: "product-1", Source: "test", Detail: "a"},
+		{Subject: "product-2", Source: "test", Detail: "b"},
+		{Subject: "product-3", Source: "test", Detail: tooLarge},
+	}
+
+	failedEvents, err := (&KinesisBus{client: client, streamName: "test"}).Put(context.Background(), events)
+	if err != nil {
+		t.Fatalf("Put returned an error: %s", err)
+	}
+
+	if len(failedEvents) != 2 || failedEvents[0].FailureCode != "ProvisionedThroughputExceededException" || failedEvents[1].FailureCode != FailureCodeEntryTooLarge {
+		codes := []string{}
+		for _, failedEvent := range failedEvents {
+			codes = append(codes, failedEvent.FailureCode)
+		}
+		t.Errorf("Got failed events with codes %v, expected b and the oversized record", codes)
+	}
+
+	for _, record := range client.records {
+		if string(record.Data) == "a" && aws.ToString(record.PartitionKey) != "product-1" {
+			t.Errorf("Got partition key %s, expected product-1", aws.ToString(record.PartitionKey))
+		}
+	}
+}
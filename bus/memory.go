@@ -0,0 +1,117 @@
+package bus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// MemoryBus records the events put on it, for tests and local runs. It can be
+// set up to reject some of them, or whole requests, to exercise the handling
+// of failures.
+type MemoryBus struct {
+	mu     sync.Mutex
+	events []types.Event
+	faults []Fault
+	err    error
+	count  int
+}
+
+var _ types.Bus = (*MemoryBus)(nil)
+
+// Fault rejects every Every-th event put on the bus with Code. Events are
+// counted across calls to Put, rejected ones included.
+type Fault struct {
+	Every   int
+	Code    string
+	Message string
+}
+
+type MemoryBusOption func(*MemoryBus)
+
+// WithFault adds a fault to the bus. When several faults match an event, the
+// first one added is reported.
+func WithFault(f Fault) MemoryBusOption {
+	return func(m *MemoryBus) {
+		m.faults = append(m.faults, f)
+	}
+}
+
+// WithPutError makes every call to Put fail as a whole with err, as if the
+// bus was unavailable.
+func WithPutError(err error) MemoryBusOption {
+	return func(m *MemoryBus) {
+		m.err = err
+	}
+}
+
+func NewMemoryBus(opts ...MemoryBusOption) *MemoryBus {
+	m := &MemoryBus{
+		events: []types.Event{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *MemoryBus) Put(ctx context.Context, events []types.Event) ([]types.FailedEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return []types.FailedEvent{}, m.err
+	}
+
+	failedEvents := []types.FailedEvent{}
+	for _, event := range events {
+		m.count++
+
+		if fault, ok := m.fault(); ok {
+			failedEvents = append(failedEvents, types.FailedEvent{
+				Event:          event,
+				FailureCode:    fault.Code,
+				FailureMessage: fault.Message,
+			})
+			continue
+		}
+
+		m.events = append(m.events, event)
+	}
+
+	return failedEvents, nil
+}
+
+func (m *MemoryBus) fault() (Fault, bool) {
+	for _, fault := range m.faults {
+		if fault.Every > 0 && m.count%fault.Every == 0 {
+			return fault, true
+		}
+	}
+
+	return Fault{}, false
+}
+
+// Events returns the events accepted so far, in the order they were put.
+func (m *MemoryBus) Events() []types.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := make([]types.Event, len(m.events))
+	copy(events, m.events)
+
+	return events
+}
+
+// Reset forgets the events accepted so far and restarts the count of events
+// used by faults.
+func (m *MemoryBus) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = []types.Event{}
+	m.count = 0
+}
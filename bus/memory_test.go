@@ -0,0 +1,38 @@
+//go:build unit
+// +build unit
+
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryBusFailsEveryNthEvent(t *testing.T) {
+	bus := NewMemoryBus(WithFault(Fault{Every: 3, Code: "ThrottlingException"}))
+
+	failedEvents, _ := bus.Put(context.Background(), testEvents("1", "2", "3", "4"))
+	moreFailedEvents, _ := bus.Put(context.Background(), testEvents("5", "6"))
+	failedEvents = append(failedEvents, moreFailedEvents...)
+
+	if len(failedEvents) != 2 || failedEvents[0].Detail != "3" || failedEvents[1].Detail != "6" || failedEvents[1].FailureCode != "ThrottlingException" {
+		t.Errorf("Got failed events %+v, expected 3 and 6", failedEvents)
+	}
+
+	if events := bus.Events(); len(events) != 4 || events[3].Detail != "5" {
+		t.Errorf("Got events %+v, expected 1, 2, 4 and 5", events)
+	}
+}
+
+func TestMemoryBusFailsWholeRequests(t *testing.T) {
+	unavailable := errors.New("unavailable")
+	bus := NewMemoryBus(WithPutError(unavailable))
+
+	if _, err := bus.Put(context.Background(), testEvents("1")); !errors.Is(err, unavailable) {
+		t.Errorf("Got error %v, expected %v", err, unavailable)
+	}
+	if len(bus.Events()) != 0 {
+		t.Errorf("Got events %+v, expected none", bus.Events())
+	}
+}
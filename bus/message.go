@@ -0,0 +1,69 @@
+package bus
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// Names of the message attributes set on SNS and SQS messages.
+const (
+	AttributeDetailType = "DetailType"
+	AttributeSource     = "Source"
+)
+
+// messageAttributes are the attributes that subscribers can filter messages
+// on, without parsing their body.
+func messageAttributes(event types.Event) map[string]string {
+	attributes := map[string]string{}
+	if event.DetailType != "" {
+		attributes[AttributeDetailType] = event.DetailType
+	}
+	if event.Source != "" {
+		attributes[AttributeSource] = event.Source
+	}
+
+	return attributes
+}
+
+// messageSize computes the size of a message the way SNS and SQS count it
+// against the request size limit: its body and the names, types and values
+// of its attributes.
+func messageSize(event types.Event) int {
+	size := len(event.Detail)
+	for name, value := range messageAttributes(event) {
+		size += len(name) + len("String") + len(value)
+	}
+
+	return size
+}
+
+// partitionKey keeps the events about a product in order in the queues and
+// streams that order by key. Events without a subject are about no product in
+// particular and share the key of their source.
+func partitionKey(event types.Event) string {
+	if event.Subject != "" {
+		return event.Subject
+	}
+
+	return event.Source
+}
+
+// batchFailure finds the event of a batch entry reported as failed by its id,
+// the position of the event in the batch.
+func batchFailure(batchEvents []laneEvent, id string, code string, message string) (laneFailure, error) {
+	i, err := strconv.Atoi(id)
+	if err != nil || i < 0 || i >= len(batchEvents) {
+		return laneFailure{}, fmt.Errorf("unknown batch entry id '%s'", id)
+	}
+
+	return laneFailure{
+		index: batchEvents[i].index,
+		FailedEvent: types.FailedEvent{
+			Event:          batchEvents[i].event,
+			FailureCode:    code,
+			FailureMessage: message,
+		},
+	}, nil
+}
@@ -0,0 +1,95 @@
+package bus
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+const (
+	// PublishBatch limits, see
+	// https://docs.aws.amazon.com/sns/latest/api/API_PublishBatch.html
+	maxSNSEntriesPerRequest = 10
+	maxSNSRequestSize       = 256 * 1024
+)
+
+// snsClient is the part of the SNS client used by the bus.
+type snsClient interface {
+	PublishBatch(context.Context, *sns.PublishBatchInput, ...func(*sns.Options)) (*sns.PublishBatchOutput, error)
+}
+
+// SNSBus publishes the detail of events as messages to an SNS topic, with
+// their detail type and source as message attributes so that subscriptions
+// can filter on them.
+type SNSBus struct {
+	client   snsClient
+	topicArn string
+}
+
+var _ types.Bus = (*SNSBus)(nil)
+
+func NewSNSBus(ctx context.Context, topicArn string) *SNSBus {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	return &SNSBus{
+		client:   sns.NewFromConfig(cfg),
+		topicArn: topicArn,
+	}
+}
+
+func (s *SNSBus) Put(ctx context.Context, events []types.Event) ([]types.FailedEvent, error) {
+	return putLanes(events, DefaultConcurrency, func(lane []laneEvent) ([]laneFailure, error) {
+		return batchEvents(lane, maxSNSEntriesPerRequest, maxSNSRequestSize, maxSNSRequestSize, messageSize, func(batchEvents []laneEvent) ([]laneFailure, error) {
+			return s.publishBatch(ctx, batchEvents)
+		})
+	})
+}
+
+func (s *SNSBus) publishBatch(ctx context.Context, batchEvents []laneEvent) ([]laneFailure, error) {
+	entries := make([]snstypes.PublishBatchRequestEntry, len(batchEvents))
+
+	for i, batchEvent := range batchEvents {
+		attributes := map[string]snstypes.MessageAttributeValue{}
+		for name, value := range messageAttributes(batchEvent.event) {
+			attributes[name] = snstypes.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(value),
+			}
+		}
+
+		entries[i] = snstypes.PublishBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			Message:           aws.String(batchEvent.event.Detail),
+			MessageAttributes: attributes,
+		}
+	}
+
+	result, err := s.client.PublishBatch(ctx, &sns.PublishBatchInput{
+		TopicArn:                   &s.topicArn,
+		PublishBatchRequestEntries: entries,
+	})
+	if err != nil {
+		return []laneFailure{}, err
+	}
+
+	failures := make([]laneFailure, 0, len(result.Failed))
+	for _, failed := range result.Failed {
+		failure, err := batchFailure(batchEvents, aws.ToString(failed.Id), aws.ToString(failed.Code), aws.ToString(failed.Message))
+		if err != nil {
+			return failures, err
+		}
+		failures = append(failures, failure)
+	}
+
+	return failures, nil
+}
@@ -0,0 +1,65 @@
+//go:build unit
+// +build unit
+
+package bus
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// fakeSNS fails the entries whose message is in failures with the given code.
+type fakeSNS struct {
+	failures map[string]string
+	entries  []snstypes.PublishBatchRequestEntry
+	mu       sync.Mutex
+}
+
+func (f *fakeSNS) PublishBatch(ctx context.Context, input *sns.PublishBatchInput, opts ...func(*sns.Options)) (*sns.PublishBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	output := &sns.PublishBatchOutput{}
+	for _, entry := range input.PublishBatchRequestEntries {
+		f.entries = append(f.entries, entry)
+
+		if code, ok := f.failures[aws.ToString(entry.Message)]; ok {
+			output.Failed = append(output.Failed, snstypes.BatchResultErrorEntry{Id: entry.Id, Code: aws.String(code)})
+			continue
+		}
+
+		output.Successful = append(output.Successful, snstypes.PublishBatchResultEntry{Id: entry.Id})
+	}
+
+	return output, nil
+}
+
+func TestSNSBusReportsFailedEntries(t *testing.T) {
+	details := make([]string, 25)
+	for i := range details {
+		details[i] = strconv.Itoa(i)
+	}
+
+	client := &fakeSNS{failures: map[string]string{"3": "InternalError", "17": "KMSThrottling"}}
+	failedEvents, err := (&SNSBus{client: client, topicArn: "test"}).Put(context.Background(), testEvents(details...))
+	if err != nil {
+		t.Fatalf("Put returned an error: %s", err)
+	}
+
+	if len(failedEvents) != 2 || failedEvents[0].Detail != "3" || failedEvents[1].Detail != "17" || failedEvents[1].FailureCode != "KMSThrottling" {
+		t.Errorf("Got failed events %+v, expected 3 and 17", failedEvents)
+	}
+
+	if len(client.entries) != 25 {
+		t.Fatalf("Got %d entries published, expected 25", len(client.entries))
+	}
+	if detailType := client.entries[0].MessageAttributes[AttributeDetailType]; aws.ToString(detailType.StringValue) != "Test" {
+		t.Errorf("Got DetailType attribute %+v, expected Test", detailType)
+	}
+}
@@ -0,0 +1,104 @@
+package bus
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// SendMessageBatch limits, see
+	// https://docs.aws.amazon.com/AWSSimpleQueueService/latest/APIReference/API_SendMessageBatch.html
+	maxSQSEntriesPerRequest = 10
+	maxSQSRequestSize       = 256 * 1024
+)
+
+// sqsClient is the part of the SQS client used by the bus.
+type sqsClient interface {
+	SendMessageBatch(context.Context, *sqs.SendMessageBatchInput, ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+}
+
+// SQSBus sends the detail of events as messages to a FIFO queue. Messages are
+// grouped by product, so that consumers receive the changes of a product in
+// order, and deduplicated by event id.
+type SQSBus struct {
+	client   sqsClient
+	queueUrl string
+}
+
+var _ types.Bus = (*SQSBus)(nil)
+
+func NewSQSBus(ctx context.Context, queueUrl string) *SQSBus {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	return &SQSBus{
+		client:   sqs.NewFromConfig(cfg),
+		queueUrl: queueUrl,
+	}
+}
+
+func (s *SQSBus) Put(ctx context.Context, events []types.Event) ([]types.FailedEvent, error) {
+	return putLanes(events, DefaultConcurrency, func(lane []laneEvent) ([]laneFailure, error) {
+		return batchEvents(lane, maxSQSEntriesPerRequest, maxSQSRequestSize, maxSQSRequestSize, messageSize, func(batchEvents []laneEvent) ([]laneFailure, error) {
+			return s.sendMessageBatch(ctx, batchEvents)
+		})
+	})
+}
+
+func (s *SQSBus) sendMessageBatch(ctx context.Context, batchEvents []laneEvent) ([]laneFailure, error) {
+	entries := make([]sqstypes.SendMessageBatchRequestEntry, len(batchEvents))
+
+	for i, batchEvent := range batchEvents {
+		event := batchEvent.event
+
+		attributes := map[string]sqstypes.MessageAttributeValue{}
+		for name, value := range messageAttributes(event) {
+			attributes[name] = sqstypes.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(value),
+			}
+		}
+
+		entry := sqstypes.SendMessageBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			MessageBody:       aws.String(event.Detail),
+			MessageAttributes: attributes,
+			MessageGroupId:    aws.String(partitionKey(event)),
+		}
+		// Without an id, the queue has to deduplicate on the content.
+		if event.Id != "" {
+			entry.MessageDeduplicationId = aws.String(event.Id)
+		}
+
+		entries[i] = entry
+	}
+
+	result, err := s.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: &s.queueUrl,
+		Entries:  entries,
+	})
+	if err != nil {
+		return []laneFailure{}, err
+	}
+
+	failures := make([]laneFailure, 0, len(result.Failed))
+	for _, failed := range result.Failed {
+		failure, err := batchFailure(batchEvents, aws.ToString(failed.Id), aws.ToString(failed.Code), aws.ToString(failed.Message))
+		if err != nil {
+			return failures, err
+		}
+		failures = append(failures, failure)
+	}
+
+	return failures, nil
+}
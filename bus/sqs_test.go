@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package bus
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// fakeSQS fails the entries whose body is in failures with the given code.
+type fakeSQS struct {
+	failures map[string]string
+	entries  []sqstypes.SendMessageBatchRequestEntry
+	mu       sync.Mutex
+}
+
+func (f *fakeSQS) SendMessageBatch(ctx context.Context, input *sqs.SendMessageBatchInput, opts ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	output := &sqs.SendMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		f.entries = append(f.entries, entry)
+
+		if code, ok := f.failures[aws.ToString(entry.MessageBody)]; ok {
+			output.Failed = append(output.Failed, sqstypes.BatchResultErrorEntry{Id: entry.Id, Code: aws.String(code)})
+			continue
+		}
+
+		output.Successful = append(output.Successful, sqstypes.SendMessageBatchResultEntry{Id: entry.Id})
+	}
+
+	return output, nil
+}
+
+func TestSQSBusGroupsMessagesByProduct(t *testing.T) {
+	client := &fakeSQS{failures: map[string]string{"b": "InvalidParameterValue"}}
+	events := []types.Event{
+		{Id: "1#ProductCreated", Subject: "product-1", Source: "test", Detail: "a"},
+		{Id: "2#ProductCreated", Subject: "product-2", Source: "test", Detail: "b"},
+		{Source: "test", Detail: "c"},
+	}
+
+	failedEvents, err := (&SQSBus{client: client, queueUrl: "test"}).Put(context.Background(), events)
+	if err != nil {
+		t.Fatalf("Put returned an error: %s", err)
+	}
+
+	if len(failedEvents) != 1 || failedEvents[0].Detail != "b" || failedEvents[0].FailureCode != "InvalidParameterValue" {
+		t.Errorf("Got failed events %+v, expected b", failedEvents)
+	}
+
+	entries := map[string]sqstypes.SendMessageBatchRequestEntry{}
+	for _, entry := range client.entries {
+		entries[aws.ToString(entry.MessageBody)] = entry
+	}
+
+	if a := entries["a"]; aws.ToString(a.MessageGroupId) != "product-1" || aws.ToString(a.MessageDeduplicationId) != "1#ProductCreated" {
+		t.Errorf("Got group %s and deduplication id %s, expected product-1 and the event id", aws.ToString(a.MessageGroupId), aws.ToString(a.MessageDeduplicationId))
+	}
+	if c := entries["c"]; aws.ToString(c.MessageGroupId) != "test" || c.MessageDeduplicationId != nil {
+		t.Errorf("Got group %s and deduplication id %v, expected the source and none", aws.ToString(c.MessageGroupId), c.MessageDeduplicationId)
+	}
+}
@@ -0,0 +1,107 @@
+// Package cloudevents encodes and decodes the product events published by the
+// stream as CloudEvents 1.0 in structured JSON mode. Consumers can use Decode,
+// or DecodeEventBridge on events delivered by an EventBridge rule, and then
+// read the product with Event.DataAs.
+package cloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	SpecVersion = "1.0"
+	// ContentType is the media type of a whole event in structured mode.
+	ContentType = "application/cloudevents+json"
+)
+
+var ErrInvalidEvent = errors.New("invalid CloudEvent")
+
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            *time.Time      `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+
+	// SchemaVersion is the version of DataSchema the data conforms to. It is
+	// an extension attribute, so its name is lowercase like the others.
+	SchemaVersion int `json:"schemaversion,omitempty"`
+}
+
+// New returns an event carrying data encoded as JSON.
+func New(id string, source string, eventType string, data interface{}) (Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("unable to encode event data: %w", err)
+	}
+
+	return Event{
+		SpecVersion:     SpecVersion,
+		Id:              id,
+		Source:          source,
+		Type:            eventType,
+		DataContentType: "application/json",
+		Data:            encoded,
+	}, nil
+}
+
+// Validate checks that the required attributes are set.
+func (e Event) Validate() error {
+	if e.SpecVersion != SpecVersion {
+		return fmt.Errorf("%w: unsupported specversion '%s'", ErrInvalidEvent, e.SpecVersion)
+	}
+
+	for name, value := range map[string]string{"id": e.Id, "source": e.Source, "type": e.Type} {
+		if value == "" {
+			return fmt.Errorf("%w: missing '%s' attribute", ErrInvalidEvent, name)
+		}
+	}
+
+	return nil
+}
+
+// DataAs decodes the data of an event with JSON content into v.
+func (e Event) DataAs(v interface{}) error {
+	if e.DataContentType != "" && e.DataContentType != "application/json" {
+		return fmt.Errorf("%w: unsupported datacontenttype '%s'", ErrInvalidEvent, e.DataContentType)
+	}
+
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("unable to decode event data: %w", err)
+	}
+
+	return nil
+}
+
+func Decode(data []byte) (Event, error) {
+	event := Event{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return Event{}, fmt.Errorf("%w: %s", ErrInvalidEvent, err)
+	}
+
+	if err := event.Validate(); err != nil {
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+// DecodeEventBridge decodes the CloudEvent in the detail of an event delivered
+// by EventBridge.
+func DecodeEventBridge(data []byte) (Event, error) {
+	envelope := struct {
+		Detail json.RawMessage `json:"detail"`
+	}{}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return Event{}, fmt.Errorf("%w: %s", ErrInvalidEvent, err)
+	}
+
+	return Decode(envelope.Detail)
+}
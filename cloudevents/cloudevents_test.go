@@ -0,0 +1,74 @@
+//go:build unit
+// +build unit
+
+package cloudevents
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeEventBridge(t *testing.T) {
+	data := []byte(`{
+		"version": "0",
+		"detail-type": "ProductCreated",
+		"source": "serverless-go-demo",
+		"detail": {
+			"specversion": "1.0",
+			"id": "1",
+			"source": "serverless-go-demo",
+			"type": "serverless-go-demo.product.created",
+			"subject": "iXR",
+			"time": "2022-01-01T10:00:00Z",
+			"datacontenttype": "application/json",
+			"data": {"id": "iXR", "name": "iPhone XML", "price": 0.5}
+		}
+	}`)
+
+	event, err := DecodeEventBridge(data)
+	if err != nil {
+		t.Fatalf("DecodeEventBridge returned an error: %s", err)
+	}
+
+	if event.Subject != "iXR" || event.Time == nil || event.Time.Hour() != 10 {
+		t.Errorf("Got unexpected event %+v", event)
+	}
+
+	product := struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}{}
+	if err := event.DataAs(&product); err != nil || product.Name != "iPhone XML" || product.Price != 0.5 {
+		t.Errorf("DataAs returned %+v, %v", product, err)
+	}
+}
+
+func TestDecodeRejectsInvalidEvents(t *testing.T) {
+	tests := map[string]string{
+		"missing id":          `{"specversion": "1.0", "source": "s", "type": "t"}`,
+		"unknown specversion": `{"specversion": "0.3", "id": "1", "source": "s", "type": "t"}`,
+		"not JSON":            `specversion=1.0`,
+	}
+
+	for name, data := range tests {
+		if _, err := Decode([]byte(data)); !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("%s: got error %v, expected ErrInvalidEvent", name, err)
+		}
+	}
+}
+
+func TestNewRoundTrip(t *testing.T) {
+	event, err := New("1", "serverless-go-demo", "serverless-go-demo.product.deleted", map[string]string{"id": "iXR"})
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+
+	if err := event.Validate(); err != nil {
+		t.Errorf("New returned an invalid event: %s", err)
+	}
+
+	data := map[string]string{}
+	if err := event.DataAs(&data); err != nil || data["id"] != "iXR" {
+		t.Errorf("DataAs returned %v, %v", data, err)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/auth"
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/encryption"
+	"github.com/aws-samples/serverless-go-demo/handlers"
+	"github.com/aws-samples/serverless-go-demo/store"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	storeType := flag.String("store", "memory", "product store to use: memory or dynamodb")
+	tableName := flag.String("table", os.Getenv("TABLE"), "DynamoDB table name when using the dynamodb store")
+	jwksFile := flag.String("jwks", "", "JWKS file used to verify bearer tokens; the API is open when empty")
+	issuer := flag.String("jwt-issuer", "", "expected 'iss' claim of bearer tokens")
+	audience := flag.String("jwt-audience", "", "expected 'aud' claim of bearer tokens")
+	apiKeys := flag.Bool("api-keys", false, "require an X-Api-Key header and serve the /keys admin endpoints")
+	keyFile := flag.String("encryption-key-file", "", "key file used to encrypt the attributes named by -encrypted-attributes")
+	encryptedAttributes := flag.String("encrypted-attributes", "", "comma-separated product attributes to encrypt at rest")
+	maxBodySize := flag.Int("max-body-size", domain.DefaultMaxBodySize, "largest product body in bytes")
+	corsOrigins := flag.String("cors-origins", "", "comma-separated origins allowed to call the API from a browser, e.g. 'https://*.example.com'")
+	rateLimits := flag.String("rate-limits", "", "rate limits per route as JSON, e.g. '{\"default\": \"50/1s:100\"}'; disabled when empty")
+	flag.Parse()
+
+	// The search index is kept in memory. It is fed from the outbox of the
+	// memory store, and built once at startup from the dynamodb store.
+	searchIndex := store.NewMemorySearchIndex()
+	projector := domain.NewSearchProjector(searchIndex)
+
+	var productStore types.Store
+	switch *storeType {
+	case "memory":
+		memoryStore := store.NewMemoryStore(store.WithOutbox())
+		go projectOutbox(memoryStore, projector)
+		productStore = memoryStore
+	case "dynamodb":
+		if *tableName == "" {
+			log.Fatal("Need -table flag or TABLE environment variable for the dynamodb store")
+		}
+		productStore = store.NewDynamoDBStore(context.TODO(), *tableName)
+		if _, err := projector.Rebuild(context.TODO(), productStore); err != nil {
+			log.Fatalf("unable to build the search index, %v", err)
+		}
+	default:
+		log.Fatalf("unknown store %q", *storeType)
+	}
+
+	if *encryptedAttributes != "" {
+		if *keyFile == "" {
+			log.Fatal("Need -encryption-key-file flag to encrypt attributes")
+		}
+		provider, err := encryption.LoadKeyFile(*keyFile)
+		if err != nil {
+			log.Fatalf("unable to load encryption keys, %v", err)
+		}
+		productStore = store.NewEncryptedStore(productStore, provider, strings.Split(*encryptedAttributes, ",")...)
+	}
+
+	products := domain.NewProductsDomain(productStore, domain.WithMaxBodySize(*maxBodySize))
+	handler := handlers.NewAPIGatewayV2Handler(products)
+	routes := handler.Routes()
+	routes = append(routes, handlers.NewSearchHandler(domain.NewSearch(searchIndex, productStore)).Routes()...)
+
+	var authorizer *handlers.APIKeyAuthorizerHandler
+	if *apiKeys {
+		apiKeysDomain := domain.NewAPIKeysDomain(store.NewMemoryAPIKeyStore())
+		authorizer = handlers.NewAPIKeyAuthorizerHandler(apiKeysDomain)
+		routes = append(routes, handlers.NewAPIKeysHandler(apiKeysDomain).Routes()...)
+	}
+
+	routes = append(routes, handlers.OpenAPIRoute(routes))
+	router := handlers.NewRouter(routes...)
+
+	fn := handlers.Validate(routes)(router.Handler)
+	fn = handlers.Idempotent(store.NewMemoryIdempotencyStore(), handlers.DefaultIdempotencyTTL)(fn)
+	if *rateLimits != "" {
+		config, err := handlers.ParseRateLimitConfig(*rateLimits)
+		if err != nil {
+			log.Fatalf("unable to load rate limits, %v", err)
+		}
+		limiter := domain.NewRateLimiter(store.NewMemoryRateLimitStore())
+		fn = handlers.RateLimit(limiter, config, routes)(fn)
+	}
+	if *jwksFile != "" || authorizer != nil {
+		var verifier *auth.JWTVerifier
+		if *jwksFile != "" {
+			keys, err := auth.LoadJWKS(*jwksFile)
+			if err != nil {
+				log.Fatalf("unable to load JWKS, %v", err)
+			}
+			verifier = auth.NewJWTVerifier(keys, *issuer, *audience)
+		}
+		fn = handlers.Authorize(routes, verifier)(fn)
+	}
+	if authorizer != nil {
+		fn = authorizer.Emulate(routes)(fn)
+	}
+	fn = handlers.Compress(handlers.DefaultCompressionThreshold)(fn)
+	if *corsOrigins != "" {
+		corsConfig := handlers.DefaultCORSConfig()
+		corsConfig.AllowedOrigins = strings.Split(*corsOrigins, ",")
+		fn = handlers.CORS(corsConfig)(fn)
+	}
+
+	log.Printf("Serving the products API with the %s store on http://%s", *storeType, *addr)
+	log.Fatal(http.ListenAndServe(*addr, handlers.NewHTTPAdapter(fn)))
+}
+
+// projectOutbox projects the changes of the memory store on the search index
+// every second, like the table stream does in the cloud.
+func projectOutbox(outbox types.Outbox, projector *domain.SearchProjector) {
+	for range time.Tick(time.Second) {
+		changes, err := outbox.Pending(context.TODO(), domain.DefaultOutboxBatchSize)
+		if err != nil || len(changes) == 0 {
+			continue
+		}
+
+		if err := projector.Project(context.TODO(), changes); err != nil {
+			log.Printf("unable to project %d changes on the search index, %v", len(changes), err)
+			continue
+		}
+
+		ids := make([]string, len(changes))
+		for i, change := range changes {
+			ids[i] = change.Id
+		}
+		outbox.Remove(context.TODO(), ids)
+	}
+}
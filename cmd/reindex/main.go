@@ -0,0 +1,35 @@
+// Command reindex rebuilds the search index from a full scan of the products
+// table, such as after the index table was lost or the terms of products
+// changed.
+//
+//	reindex -table <products table> -search-table <search table>
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/store"
+)
+
+func main() {
+	tableName := flag.String("table", os.Getenv("TABLE"), "DynamoDB table of the products")
+	searchTable := flag.String("search-table", os.Getenv("SEARCH_TABLE"), "DynamoDB table of the search index")
+	flag.Parse()
+
+	if *tableName == "" || *searchTable == "" {
+		log.Fatal("Need -table and -search-table flags")
+	}
+
+	projector := domain.NewSearchProjector(store.NewDynamoDBSearchIndex(context.TODO(), *searchTable))
+
+	indexed, err := projector.Rebuild(context.TODO(), store.NewDynamoDBStore(context.TODO(), *tableName))
+	if err != nil {
+		log.Fatalf("unable to rebuild the search index after %d products, %v", indexed, err)
+	}
+
+	log.Printf("Indexed %d products", indexed)
+}
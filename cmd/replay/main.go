@@ -0,0 +1,114 @@
+// Command replay lists the dead letters of the products stream and publishes
+// their events again.
+//
+//	# List the dead letters of the last day rejected for lack of permissions
+//	replay -table <dead-letter table> -code AccessDeniedException -since 24h
+//
+//	# Publish them again to the bus named by BUS_TYPE and its variables
+//	EVENT_BUS_NAME=<bus> replay -table <dead-letter table> -code AccessDeniedException -since 24h -publish
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/bus"
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/store"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+func main() {
+	tableName := flag.String("table", os.Getenv("DEAD_LETTER_TABLE"), "DynamoDB table of the dead letters")
+	file := flag.String("file", os.Getenv("DEAD_LETTER_FILE"), "file of the dead letters, instead of -table")
+	code := flag.String("code", "", "only dead letters with this failure code")
+	since := flag.String("since", "", "only dead letters that failed since this RFC 3339 time, or this long ago, e.g. '24h'")
+	until := flag.String("until", "", "only dead letters that failed before this RFC 3339 time, or this long ago")
+	publish := flag.Bool("publish", false, "publish the events of the dead letters through the bus configured by BUS_TYPE")
+	flag.Parse()
+
+	var deadLetters types.DeadLetterStore
+	switch {
+	case *file != "":
+		deadLetters = store.NewFileDeadLetterStore(*file)
+	case *tableName != "":
+		deadLetters = store.NewDynamoDBDeadLetterStore(context.TODO(), *tableName)
+	default:
+		log.Fatal("Need -table or -file flag")
+	}
+
+	filter := types.DeadLetterFilter{FailureCode: *code}
+	var err error
+	if filter.Since, err = parseTime(*since); err != nil {
+		log.Fatalf("invalid -since, %v", err)
+	}
+	if filter.Until, err = parseTime(*until); err != nil {
+		log.Fatalf("invalid -until, %v", err)
+	}
+
+	if !*publish {
+		list(deadLetters, filter)
+		return
+	}
+
+	eventBus, err := bus.NewBusFromEnv(context.TODO())
+	if err != nil {
+		log.Fatalf("unable to configure the event bus, %v", err)
+	}
+
+	result, err := domain.NewDeadLetterReplayer(deadLetters, eventBus).Replay(context.TODO(), filter)
+	fmt.Printf("replayed %d, failed again %d, skipped %d without events\n", len(result.Replayed), len(result.Failed), len(result.Skipped))
+	for _, id := range result.Failed {
+		fmt.Printf("failed again: %s\n", id)
+	}
+	for _, id := range result.Skipped {
+		fmt.Printf("skipped: %s\n", id)
+	}
+	if err != nil {
+		log.Fatalf("replay stopped, %v", err)
+	}
+}
+
+func list(deadLetters types.DeadLetterStore, filter types.DeadLetterFilter) {
+	matching, err := deadLetters.List(context.TODO(), filter)
+	if err != nil {
+		log.Fatalf("unable to list dead letters, %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFAILED AT\tCODE\tATTEMPTS\tEVENTS\tMESSAGE")
+	for _, deadLetter := range matching {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n",
+			deadLetter.Id,
+			deadLetter.FailedAt.Format(time.RFC3339),
+			deadLetter.FailureCode,
+			deadLetter.Attempts,
+			len(deadLetter.Events),
+			deadLetter.FailureMessage,
+		)
+	}
+	w.Flush()
+}
+
+// parseTime reads an RFC 3339 time, or a duration before now.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is neither an RFC 3339 time nor a duration", value)
+	}
+
+	return time.Now().Add(-d), nil
+}
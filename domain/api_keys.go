@@ -0,0 +1,233 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+var (
+	ErrAPIKeyInvalid  = errors.New("invalid API key")
+	ErrAPIKeyExpired  = errors.New("API key is expired")
+	ErrAPIKeyRevoked  = errors.New("API key is revoked")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrAPIKeyRequest  = errors.New("invalid API key request")
+)
+
+// apiKeyPrefix makes our keys easy to recognize, e.g. by secret scanners.
+const apiKeyPrefix = "sgd"
+
+// IssuedAPIKey is returned once when a key is issued or rotated. The secret
+// can't be recovered afterwards.
+type IssuedAPIKey struct {
+	types.APIKey
+	Secret string `json:"secret"`
+}
+
+type APIKeys struct {
+	store types.APIKeyStore
+	now   func() time.Time
+}
+
+func NewAPIKeysDomain(s types.APIKeyStore) *APIKeys {
+	return &APIKeys{
+		store: s,
+		now:   time.Now,
+	}
+}
+
+func (d *APIKeys) Issue(ctx context.Context, owner string, scopes []string, expiresAt *time.Time) (*IssuedAPIKey, error) {
+	if strings.TrimSpace(owner) == "" {
+		return nil, fmt.Errorf("%w: missing owner", ErrAPIKeyRequest)
+	}
+
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrAPIKeyRequest)
+	}
+
+	if expiresAt != nil && !expiresAt.After(d.now()) {
+		return nil, fmt.Errorf("%w: expiry is in the past", ErrAPIKeyRequest)
+	}
+
+	id, err := randomString(8, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, hash, err := newSecret(id)
+	if err != nil {
+		return nil, err
+	}
+
+	key := types.APIKey{
+		Id:        id,
+		Owner:     owner,
+		Scopes:    scopes,
+		Hash:      hash,
+		CreatedAt: d.now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+
+	if err := d.store.Put(ctx, key); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return &IssuedAPIKey{APIKey: key, Secret: secret}, nil
+}
+
+// Rotate replaces the secret of a key. The previous secret stays valid for the
+// grace period so that clients can switch without downtime.
+func (d *APIKeys) Rotate(ctx context.Context, id string, grace time.Duration) (*IssuedAPIKey, error) {
+	key, err := d.activeKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, hash, err := newSecret(id)
+	if err != nil {
+		return nil, err
+	}
+
+	key.PreviousHash = ""
+	key.PreviousExpiresAt = nil
+	if grace > 0 {
+		previousExpiresAt := d.now().Add(grace).UTC()
+		key.PreviousHash = key.Hash
+		key.PreviousExpiresAt = &previousExpiresAt
+	}
+	key.Hash = hash
+
+	if err := d.store.Put(ctx, *key); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return &IssuedAPIKey{APIKey: *key, Secret: secret}, nil
+}
+
+func (d *APIKeys) Revoke(ctx context.Context, id string) error {
+	key, err := d.store.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	if key == nil {
+		return ErrAPIKeyNotFound
+	}
+
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	revokedAt := d.now().UTC()
+	key.RevokedAt = &revokedAt
+
+	if err := d.store.Put(ctx, *key); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// Authenticate returns the key matching a secret presented by a client.
+func (d *APIKeys) Authenticate(ctx context.Context, secret string) (*types.APIKey, error) {
+	id, ok := parseSecret(secret)
+	if !ok {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	key, err := d.store.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	if key == nil {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	hash := hashSecret(secret)
+	matches := subtle.ConstantTimeCompare([]byte(hash), []byte(key.Hash)) == 1
+	if !matches && key.PreviousHash != "" && key.PreviousExpiresAt != nil && d.now().Before(*key.PreviousExpiresAt) {
+		matches = subtle.ConstantTimeCompare([]byte(hash), []byte(key.PreviousHash)) == 1
+	}
+
+	if !matches {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	if key.ExpiresAt != nil && !d.now().Before(*key.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	return key, nil
+}
+
+func (d *APIKeys) activeKey(ctx context.Context, id string) (*types.APIKey, error) {
+	key, err := d.store.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	if key == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	if key.ExpiresAt != nil && !d.now().Before(*key.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	return key, nil
+}
+
+// Secrets look like "sgd_<id>_<random>", so the key can be found without
+// storing anything that would allow recovering the secret.
+func newSecret(id string) (string, string, error) {
+	random, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret := strings.Join([]string{apiKeyPrefix, id, random}, "_")
+
+	return secret, hashSecret(secret), nil
+}
+
+func parseSecret(secret string) (string, bool) {
+	parts := strings.SplitN(secret, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+
+	return parts[1], true
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate random bytes: %w", err)
+	}
+
+	return encode(b), nil
+}
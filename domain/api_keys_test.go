@@ -0,0 +1,84 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/store"
+)
+
+func TestAPIKeysLifecycle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	apiKeys := NewAPIKeysDomain(store.NewMemoryAPIKeyStore())
+	apiKeys.now = func() time.Time { return now }
+
+	issued, err := apiKeys.Issue(ctx, "partner", []string{"products:read"}, nil)
+	if err != nil {
+		t.Fatalf("Issue returned an error: %s", err)
+	}
+
+	key, err := apiKeys.Authenticate(ctx, issued.Secret)
+	if err != nil {
+		t.Fatalf("Authenticate returned an error: %s", err)
+	}
+
+	if key.Owner != "partner" || len(key.Scopes) != 1 {
+		t.Errorf("Authenticate returned unexpected key %v", key)
+	}
+
+	if _, err := apiKeys.Authenticate(ctx, issued.Secret+"x"); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Errorf("Got error %v for a wrong secret, expected ErrAPIKeyInvalid", err)
+	}
+
+	rotated, err := apiKeys.Rotate(ctx, issued.Id, time.Hour)
+	if err != nil {
+		t.Fatalf("Rotate returned an error: %s", err)
+	}
+
+	if _, err := apiKeys.Authenticate(ctx, rotated.Secret); err != nil {
+		t.Errorf("Got error %v for the new secret", err)
+	}
+
+	if _, err := apiKeys.Authenticate(ctx, issued.Secret); err != nil {
+		t.Errorf("Got error %v for the previous secret during the grace period", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := apiKeys.Authenticate(ctx, issued.Secret); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Errorf("Got error %v for the previous secret after the grace period", err)
+	}
+
+	if err := apiKeys.Revoke(ctx, issued.Id); err != nil {
+		t.Fatalf("Revoke returned an error: %s", err)
+	}
+
+	if _, err := apiKeys.Authenticate(ctx, rotated.Secret); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("Got error %v for a revoked key, expected ErrAPIKeyRevoked", err)
+	}
+}
+
+func TestAPIKeyExpiry(t *testing.T) {
+	ctx := context.Background()
+	apiKeys := NewAPIKeysDomain(store.NewMemoryAPIKeyStore())
+
+	expiresAt := time.Now().Add(time.Minute)
+	issued, err := apiKeys.Issue(ctx, "partner", []string{"products:read"}, &expiresAt)
+	if err != nil {
+		t.Fatalf("Issue returned an error: %s", err)
+	}
+
+	apiKeys.now = func() time.Time { return expiresAt }
+	if _, err := apiKeys.Authenticate(ctx, issued.Secret); !errors.Is(err, ErrAPIKeyExpired) {
+		t.Errorf("Got error %v for an expired key, expected ErrAPIKeyExpired", err)
+	}
+
+	if _, err := apiKeys.Issue(ctx, "partner", nil, nil); !errors.Is(err, ErrAPIKeyRequest) {
+		t.Errorf("Got error %v for a key without scopes, expected ErrAPIKeyRequest", err)
+	}
+}
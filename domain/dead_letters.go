@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// DeadLetterReplayer publishes the events of dead letters again, once what
+// made them fail is fixed.
+type DeadLetterReplayer struct {
+	store types.DeadLetterStore
+	bus   types.Bus
+	now   func() time.Time
+}
+
+// ReplayResult tells what happened to each dead letter, by id.
+type ReplayResult struct {
+	// Replayed dead letters had all their events published and were deleted.
+	Replayed []string
+	// Skipped dead letters have no events, as their record could not be
+	// turned into events in the first place.
+	Skipped []string
+	// Failed dead letters had events rejected again. They are kept with their
+	// new failure.
+	Failed []string
+}
+
+func NewDeadLetterReplayer(s types.DeadLetterStore, b types.Bus) *DeadLetterReplayer {
+	return &DeadLetterReplayer{
+		store: s,
+		bus:   b,
+		now:   time.Now,
+	}
+}
+
+// Replay puts the events of the dead letters matching the filter on the bus,
+// one dead letter at a time. It stops at the first error from the bus or the
+// store, returning what was done until then.
+func (r *DeadLetterReplayer) Replay(ctx context.Context, filter types.DeadLetterFilter) (ReplayResult, error) {
+	result := ReplayResult{Replayed: []string{}, Skipped: []string{}, Failed: []string{}}
+
+	deadLetters, err := r.store.List(ctx, filter)
+	if err != nil {
+		return result, fmt.Errorf("%w", err)
+	}
+
+	for _, deadLetter := range deadLetters {
+		if len(deadLetter.Events) == 0 {
+			result.Skipped = append(result.Skipped, deadLetter.Id)
+			continue
+		}
+
+		failedEvents, err := r.bus.Put(ctx, deadLetter.Events)
+		if err != nil {
+			return result, fmt.Errorf("%w", err)
+		}
+
+		if len(failedEvents) == 0 {
+			if err := r.store.Delete(ctx, []string{deadLetter.Id}); err != nil {
+				return result, fmt.Errorf("%w", err)
+			}
+			result.Replayed = append(result.Replayed, deadLetter.Id)
+			continue
+		}
+
+		// Only the events rejected again are kept, so that a later replay
+		// doesn't publish the others twice.
+		deadLetter.Events = make([]types.Event, len(failedEvents))
+		for i, failedEvent := range failedEvents {
+			deadLetter.Events[i] = failedEvent.Event
+		}
+		deadLetter.FailureCode = failedEvents[0].FailureCode
+		deadLetter.FailureMessage = failedEvents[0].FailureMessage
+		deadLetter.Attempts++
+		deadLetter.FailedAt = r.now().UTC()
+
+		if err := r.store.Send(ctx, []types.DeadLetter{deadLetter}); err != nil {
+			return result, fmt.Errorf("%w", err)
+		}
+		result.Failed = append(result.Failed, deadLetter.Id)
+	}
+
+	return result, nil
+}
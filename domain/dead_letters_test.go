@@ -0,0 +1,56 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/bus"
+	"github.com/aws-samples/serverless-go-demo/store"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+func TestReplayDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	deadLetters := store.NewFileDeadLetterStore(filepath.Join(t.TempDir(), "dead-letters.ndjson"))
+	failedAt := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	deadLetters.Send(ctx, []types.DeadLetter{
+		{Id: "1", FailureCode: "AccessDeniedException", FailedAt: failedAt, Events: []types.Event{{Id: "a", Subject: "iXR"}}},
+		{Id: "2", FailureCode: "AccessDeniedException", FailedAt: failedAt.Add(time.Minute), Events: []types.Event{{Id: "b", Subject: "iXS"}, {Id: "c", Subject: "iXS"}}},
+		{Id: "3", FailureCode: FailureCodeInvalidChange, FailedAt: failedAt},
+		{Id: "4", FailureCode: "AccessDeniedException", FailedAt: failedAt.Add(-time.Hour), Events: []types.Event{{Id: "d"}}},
+	})
+
+	// The second event put on the bus, b, is rejected again.
+	eventBus := bus.NewMemoryBus(bus.WithFault(bus.Fault{Every: 2, Code: "ThrottlingException"}))
+
+	result, err := NewDeadLetterReplayer(deadLetters, eventBus).Replay(ctx, types.DeadLetterFilter{Since: failedAt})
+	if err != nil {
+		t.Fatalf("Replay returned an error: %s", err)
+	}
+
+	expected := ReplayResult{Replayed: []string{"1"}, Skipped: []string{"3"}, Failed: []string{"2"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Got result %+v, expected %+v", result, expected)
+	}
+
+	left, _ := deadLetters.List(ctx, types.DeadLetterFilter{})
+	ids := []string{}
+	for _, deadLetter := range left {
+		ids = append(ids, deadLetter.Id)
+	}
+	if !reflect.DeepEqual(ids, []string{"4", "3", "2"}) {
+		t.Fatalf("Got dead letters %v left, expected 4, 3 and 2", ids)
+	}
+
+	retried := left[2]
+	if retried.FailureCode != "ThrottlingException" || retried.Attempts != 1 || len(retried.Events) != 1 || retried.Events[0].Id != "b" {
+		t.Errorf("Got dead letter %+v, expected only event b with its new failure", retried)
+	}
+}
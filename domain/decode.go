@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DecodeError tells where a request body failed to decode. It matches
+// ErrJsonUnmarshal with errors.Is.
+type DecodeError struct {
+	// Offset is the byte offset in the body at which decoding failed, or right
+	// after the key of the field at fault.
+	Offset int64
+	// Field is the path of the field that failed, e.g. "price", when known.
+	Field string
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%s: %s in field '%s' at offset %d", ErrJsonUnmarshal, e.Err, e.Field, e.Offset)
+	}
+
+	return fmt.Sprintf("%s: %s at offset %d", ErrJsonUnmarshal, e.Err, e.Offset)
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrJsonUnmarshal
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeStrict decodes body into v, rejecting duplicate keys, fields that v
+// doesn't have and anything after the first JSON value.
+func decodeStrict(body []byte, v interface{}) error {
+	// Offset right after each key, so errors about a field can point at it.
+	keyOffsets := map[string]int64{}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if err := checkDuplicateKeys(dec, "", keyOffsets); err != nil {
+		return decodeError(dec, err, keyOffsets)
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return &DecodeError{Offset: dec.InputOffset(), Err: errors.New("unexpected data after the JSON value")}
+	}
+
+	dec = json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeError(dec, err, keyOffsets)
+	}
+
+	return nil
+}
+
+// checkDuplicateKeys reads the next value from dec and fails on objects with
+// the same key twice. Keys are compared without case, like encoding/json
+// matches them to struct fields.
+func checkDuplicateKeys(dec *json.Decoder, path string, keyOffsets map[string]int64) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		seen := map[string]bool{}
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return err
+			}
+
+			key := token.(string)
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+
+			if seen[strings.ToLower(key)] {
+				return &DecodeError{Offset: dec.InputOffset(), Field: field, Err: errors.New("duplicate key")}
+			}
+			seen[strings.ToLower(key)] = true
+			if _, ok := keyOffsets[key]; !ok {
+				keyOffsets[key] = dec.InputOffset()
+			}
+
+			if err := checkDuplicateKeys(dec, field, keyOffsets); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if err := checkDuplicateKeys(dec, fmt.Sprintf("%s[%d]", path, i), keyOffsets); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Closing delimiter
+	_, err = dec.Token()
+
+	return err
+}
+
+func decodeError(dec *json.Decoder, err error, keyOffsets map[string]int64) error {
+	var decodeErr *DecodeError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &decodeErr):
+		return decodeErr
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{Offset: syntaxErr.Offset, Err: err}
+	case errors.As(err, &typeErr):
+		return &DecodeError{
+			Offset: typeErr.Offset,
+			Field:  typeErr.Field,
+			Err:    fmt.Errorf("expected %s, got %s", typeErr.Type, typeErr.Value),
+		}
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return &DecodeError{Offset: dec.InputOffset(), Err: errors.New("unexpected end of JSON input")}
+	}
+
+	// encoding/json has no type for unknown fields, only this message.
+	if field := strings.TrimPrefix(err.Error(), "json: unknown field "); field != err.Error() {
+		field = strings.Trim(field, `"`)
+		offset, ok := keyOffsets[field]
+		if !ok {
+			offset = dec.InputOffset()
+		}
+
+		return &DecodeError{Offset: offset, Field: field, Err: errors.New("unknown field")}
+	}
+
+	return &DecodeError{Offset: dec.InputOffset(), Err: err}
+}
@@ -0,0 +1,57 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws-samples/serverless-go-demo/store"
+)
+
+func TestPutProductStrictDecoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		offset int64
+		field  string
+	}{
+		{"invalid JSON", `{"id": "1", "name": x}`, 21, ""},
+		{"unknown field", `{"id": "1", "name": "n", "price": 1, "cost": 2}`, 43, "cost"},
+		{"duplicate key", `{"id": "1", "name": "n", "price": 1, "price": 2}`, 44, "price"},
+		{"wrong type", `{"id": "1", "name": "n", "price": "1"}`, 37, "price"},
+		{"trailing data", `{"id": "1", "name": "n", "price": 1} {}`, 38, ""},
+	}
+
+	domain := NewProductsDomain(store.NewMemoryStore())
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := domain.PutProduct(context.Background(), "1", []byte(test.body))
+			if !errors.Is(err, ErrJsonUnmarshal) {
+				t.Fatalf("Got error %v, expected ErrJsonUnmarshal", err)
+			}
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Got error %T, expected a DecodeError", err)
+			}
+
+			if decodeErr.Offset != test.offset || decodeErr.Field != test.field {
+				t.Errorf("Got offset %d and field %q, expected %d and %q", decodeErr.Offset, decodeErr.Field, test.offset, test.field)
+			}
+		})
+	}
+}
+
+func TestPutProductBodyTooLarge(t *testing.T) {
+	domain := NewProductsDomain(store.NewMemoryStore(), WithMaxBodySize(32))
+
+	body := `{"id": "1", "name": "` + strings.Repeat("n", 32) + `", "price": 1}`
+	if _, err := domain.PutProduct(context.Background(), "1", []byte(body)); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Got error %v, expected ErrBodyTooLarge", err)
+	}
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// DefaultOutboxBatchSize is how many changes OutboxRelay publishes at once.
+const DefaultOutboxBatchSize = 100
+
+// DefaultMaxOutboxAttempts is how many times a change can fail with a
+// retryable code before it is sent to the dead-letter sink.
+const DefaultMaxOutboxAttempts = 3
+
+// OutboxRelay publishes the changes of an outbox through the products stream,
+// like the table stream would. A change is removed from the outbox once all
+// its events are published, so it is published at least once. Events keep the
+// ids derived from the change id, which consumers use to drop duplicates.
+type OutboxRelay struct {
+	outbox      types.Outbox
+	stream      *ProductsStream
+	projector   *SearchProjector
+	batchSize   int
+	deadLetters types.DeadLetterSink
+	maxAttempts int
+
+	// attempts counts the failures of each change by id. It only lives as
+	// long as the execution environment, like the attempts of the stream
+	// handler.
+	attempts map[string]int
+}
+
+type OutboxRelayOption func(*OutboxRelay)
+
+// WithSearchProjector projects the changes on the search index too, before
+// they are removed from the outbox, for deployments where the projector
+// can't read the table stream.
+func WithSearchProjector(p *SearchProjector) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.projector = p
+	}
+}
+
+// WithOutboxDeadLetters sends changes that failed for good, or failed
+// maxAttempts times with a retryable code, to the sink. Without a sink, they
+// are logged as DEAD_LETTER lines. Either way they are removed from the
+// outbox, so that they don't hold back the changes behind them.
+func WithOutboxDeadLetters(s types.DeadLetterSink, maxAttempts int) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.deadLetters = s
+		r.maxAttempts = maxAttempts
+	}
+}
+
+func NewOutboxRelay(o types.Outbox, s *ProductsStream, opts ...OutboxRelayOption) *OutboxRelay {
+	r := &OutboxRelay{
+		outbox:      o,
+		stream:      s,
+		batchSize:   DefaultOutboxBatchSize,
+		maxAttempts: DefaultMaxOutboxAttempts,
+		attempts:    map[string]int{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// relayFailure is why a change failed, along with its events that could not
+// be published.
+type relayFailure struct {
+	code    string
+	message string
+	events  []types.Event
+}
+
+// Relay drains the outbox and returns how many changes it published. It stops
+// at the first batch that can't be fully published, leaving the changes that
+// failed with a retryable code fewer than maxAttempts times in the outbox for
+// the next run.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	relayed := 0
+
+	for {
+		changes, err := r.outbox.Pending(ctx, r.batchSize)
+		if err != nil {
+			return relayed, fmt.Errorf("%w", err)
+		}
+
+		if len(changes) == 0 {
+			return relayed, nil
+		}
+
+		// Projecting is idempotent, so changes that fail to be published are
+		// simply projected again on the next run.
+		if r.projector != nil {
+			if err := r.projector.Project(ctx, changes); err != nil {
+				return relayed, err
+			}
+		}
+
+		failedEvents, err := r.stream.Publish(ctx, changes)
+		if err != nil {
+			return relayed, fmt.Errorf("%w", err)
+		}
+
+		// Events point to their change through their first resource.
+		failures := map[string]*relayFailure{}
+		for _, failedEvent := range failedEvents {
+			log.Printf("failed to publish %s event %s: %s %s", failedEvent.DetailType, failedEvent.Id, failedEvent.FailureCode, failedEvent.FailureMessage)
+			if len(failedEvent.Resources) == 0 {
+				continue
+			}
+
+			failure, ok := failures[failedEvent.Resources[0]]
+			if !ok {
+				failure = &relayFailure{code: failedEvent.FailureCode, message: failedEvent.FailureMessage}
+				failures[failedEvent.Resources[0]] = failure
+			}
+			if failedEvent.DetailType != "" {
+				failure.events = append(failure.events, failedEvent.Event)
+			}
+		}
+
+		published := []string{}
+		deadLetters := []types.DeadLetter{}
+		kept := 0
+		for _, change := range changes {
+			failure, ok := failures[change.Id]
+			if !ok {
+				published = append(published, change.Id)
+				delete(r.attempts, change.Id)
+				continue
+			}
+
+			r.attempts[change.Id]++
+			if types.IsRetryable(failure.code) && r.attempts[change.Id] < r.maxAttempts {
+				kept++
+				continue
+			}
+
+			rawChange, _ := json.Marshal(change)
+			deadLetters = append(deadLetters, types.DeadLetter{
+				Id:             change.Id,
+				FailureCode:    failure.code,
+				FailureMessage: failure.message,
+				Attempts:       r.attempts[change.Id],
+				FailedAt:       time.Now().UTC(),
+				Events:         failure.events,
+				Record:         rawChange,
+			})
+		}
+
+		removed := published
+		if len(deadLetters) > 0 {
+			r.sendDeadLetters(ctx, deadLetters)
+
+			for _, deadLetter := range deadLetters {
+				removed = append(removed, deadLetter.Id)
+				delete(r.attempts, deadLetter.Id)
+			}
+		}
+
+		if err := r.outbox.Remove(ctx, removed); err != nil {
+			return relayed, fmt.Errorf("%w", err)
+		}
+		relayed += len(published)
+
+		if kept > 0 || len(changes) < r.batchSize {
+			return relayed, nil
+		}
+	}
+}
+
+// sendDeadLetters sends dead letters to the sink, or logs them when there is
+// none or it fails, as keeping them in the outbox would hold back the changes
+// behind them for good.
+func (r *OutboxRelay) sendDeadLetters(ctx context.Context, deadLetters []types.DeadLetter) {
+	if r.deadLetters != nil {
+		err := r.deadLetters.Send(ctx, deadLetters)
+		if err == nil {
+			return
+		}
+		log.Printf("failed to send %d changes to the dead-letter sink, logging them instead: %v", len(deadLetters), err)
+	}
+
+	for _, deadLetter := range deadLetters {
+		data, _ := json.Marshal(deadLetter)
+		log.Printf("DEAD_LETTER %s", data)
+	}
+}
@@ -0,0 +1,165 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws-samples/serverless-go-demo/bus"
+	"github.com/aws-samples/serverless-go-demo/store"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+func TestRelayPublishesOutboxInOrder(t *testing.T) {
+	productStore := store.NewMemoryStore(store.WithOutbox())
+	products := NewProductsDomain(productStore)
+	eventBus := bus.NewMemoryBus()
+
+	ctx := context.Background()
+	products.PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1}`))
+	products.PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 2}`))
+	products.DeleteProduct(ctx, "iXR")
+
+	relayed, err := NewOutboxRelay(productStore, NewProductsStream(eventBus)).Relay(ctx)
+	if err != nil {
+		t.Fatalf("Relay returned an error: %s", err)
+	}
+
+	// The tombstone written before the deletion is a change of its own.
+	if relayed != 4 {
+		t.Errorf("Got %d changes relayed, expected 4", relayed)
+	}
+
+	detailTypes := []string{}
+	for _, event := range eventBus.Events() {
+		detailTypes = append(detailTypes, event.DetailType)
+	}
+	expected := []string{DetailTypeProductCreated, DetailTypeProductChanged, DetailTypeProductUpdated, DetailTypePriceChanged, DetailTypeProductDeleted, DetailTypeProductDelected}
+	if !reflect.DeepEqual(detailTypes, expected) {
+		t.Errorf("Got detail types %v, expected %v", detailTypes, expected)
+	}
+
+	if pending, _ := productStore.Pending(ctx, DefaultOutboxBatchSize); len(pending) != 0 {
+		t.Errorf("Got %d changes left in the outbox, expected none", len(pending))
+	}
+}
+
+func TestRelayKeepsFailedChanges(t *testing.T) {
+	productStore := store.NewMemoryStore(store.WithOutbox())
+	products := NewProductsDomain(productStore)
+	eventBus := bus.NewMemoryBus(bus.WithFault(bus.Fault{Every: 2, Code: "ThrottlingException"}))
+
+	ctx := context.Background()
+	products.PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1}`))
+	products.PutProduct(ctx, "iXS", []byte(`{"id": "iXS", "name": "iPhone XS", "price": 1}`))
+
+	relay := NewOutboxRelay(productStore, NewProductsStream(eventBus))
+	if relayed, _ := relay.Relay(ctx); relayed != 1 {
+		t.Errorf("Got %d changes relayed, expected 1", relayed)
+	}
+
+	pending, _ := productStore.Pending(ctx, DefaultOutboxBatchSize)
+	if len(pending) != 1 || pending[0].After.Id != "iXS" {
+		t.Fatalf("Got changes %+v left in the outbox, expected the creation of iXS", pending)
+	}
+
+	// The retry is the third event put on the bus, which accepts it.
+	if relayed, _ := relay.Relay(ctx); relayed != 1 {
+		t.Errorf("Got %d changes relayed on retry, expected 1", relayed)
+	}
+	if events := eventBus.Events(); len(events) != 2 || events[1].Subject != "iXS" || events[1].Id != pending[0].Id {
+		t.Errorf("Got events %+v, expected the creation of iXS with the change id", events)
+	}
+}
+
+func TestRelayProjectsChanges(t *testing.T) {
+	productStore := store.NewMemoryStore(store.WithOutbox())
+	index := store.NewMemorySearchIndex()
+
+	ctx := context.Background()
+	NewProductsDomain(productStore).PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1}`))
+
+	relay := NewOutboxRelay(productStore, NewProductsStream(bus.NewMemoryBus()), WithSearchProjector(NewSearchProjector(index)))
+	if _, err := relay.Relay(ctx); err != nil {
+		t.Fatalf("Relay returned an error: %s", err)
+	}
+
+	if postings, _ := index.Postings(ctx, []string{"iphone"}); len(postings) != 1 || postings[0].Id != "iXR" {
+		t.Errorf("Got postings %+v, expected iXR to be indexed", postings)
+	}
+}
+
+func TestRelayDeadLettersFailedChanges(t *testing.T) {
+	productStore := store.NewMemoryStore(store.WithOutbox())
+	products := NewProductsDomain(productStore)
+	deadLetters := store.NewFileDeadLetterStore(t.TempDir() + "/dead-letters.ndjson")
+
+	ctx := context.Background()
+	for _, id := range []string{"denied", "throttled", "ok"} {
+		products.PutProduct(ctx, id, []byte(`{"id": "`+id+`", "name": "`+id+`", "price": 1}`))
+	}
+	pending, _ := productStore.Pending(ctx, DefaultOutboxBatchSize)
+
+	eventBus := bus.NewMemoryBus(bus.WithFault(bus.Fault{Every: 1, Code: "AccessDeniedException"}))
+	relay := NewOutboxRelay(productStore, NewProductsStream(eventBus), WithOutboxDeadLetters(deadLetters, 2))
+	relay.batchSize = 1
+
+	// The first change is rejected for good, so it is dead-lettered at once
+	// and the relay moves on to the next ones.
+	relay.Relay(ctx)
+	letters, _ := deadLetters.List(ctx, types.DeadLetterFilter{})
+	if len(letters) != 3 || letters[0].Id != pending[0].Id || letters[0].Attempts != 1 || len(letters[0].Events) != 1 {
+		t.Fatalf("Got dead letters %+v, expected the three changes after one attempt each", letters)
+	}
+
+	if left, _ := productStore.Pending(ctx, DefaultOutboxBatchSize); len(left) != 0 {
+		t.Errorf("Got %d changes left in the outbox, expected none", len(left))
+	}
+}
+
+func TestRelayRetriesRetryableFailures(t *testing.T) {
+	productStore := store.NewMemoryStore(store.WithOutbox())
+	deadLetters := store.NewFileDeadLetterStore(t.TempDir() + "/dead-letters.ndjson")
+
+	ctx := context.Background()
+	NewProductsDomain(productStore).PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1}`))
+
+	eventBus := bus.NewMemoryBus(bus.WithFault(bus.Fault{Every: 1, Code: "ThrottlingException"}))
+	relay := NewOutboxRelay(productStore, NewProductsStream(eventBus), WithOutboxDeadLetters(deadLetters, 2))
+
+	relay.Relay(ctx)
+	if letters, _ := deadLetters.List(ctx, types.DeadLetterFilter{}); len(letters) != 0 {
+		t.Fatalf("Got dead letters %+v after the first attempt", letters)
+	}
+
+	relay.Relay(ctx)
+	letters, _ := deadLetters.List(ctx, types.DeadLetterFilter{})
+	if len(letters) != 1 || letters[0].Attempts != 2 || letters[0].FailureCode != "ThrottlingException" {
+		t.Errorf("Got dead letters %+v, expected the change after two attempts", letters)
+	}
+}
+
+func TestRelaySkipsChangesFailingForGoodWithoutSink(t *testing.T) {
+	productStore := store.NewMemoryStore(store.WithOutbox())
+	products := NewProductsDomain(productStore)
+
+	ctx := context.Background()
+	products.PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1}`))
+	products.PutProduct(ctx, "iXS", []byte(`{"id": "iXS", "name": "iPhone XS", "price": 1}`))
+
+	eventBus := bus.NewMemoryBus(bus.WithFault(bus.Fault{Every: 1, Code: "ThrottlingException"}))
+	relay := NewOutboxRelay(productStore, NewProductsStream(eventBus))
+	relay.batchSize = 1
+
+	for i := 0; i < DefaultMaxOutboxAttempts; i++ {
+		relay.Relay(ctx)
+	}
+
+	pending, _ := productStore.Pending(ctx, DefaultOutboxBatchSize)
+	if len(pending) != 1 || pending[0].After.Id != "iXS" {
+		t.Errorf("Got changes %+v left in the outbox, expected the first one to be skipped after %d attempts", pending, DefaultMaxOutboxAttempts)
+	}
+}
@@ -2,29 +2,74 @@ package domain
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/aws-samples/serverless-go-demo/auth"
 	"github.com/aws-samples/serverless-go-demo/types"
 )
 
+// AnonymousActor is recorded as the author of changes made without an
+// authenticated principal, e.g. when authorization is disabled.
+const AnonymousActor = "anonymous"
+
+// DefaultMaxBodySize is the largest request body PutProduct accepts unless
+// configured with WithMaxBodySize.
+const DefaultMaxBodySize = 64 << 10
+
 var (
 	ErrJsonUnmarshal     = errors.New("failed to parse product from request body")
 	ErrProductIdMismatch = errors.New("product ID in path does not match product ID in body")
+	ErrBodyTooLarge      = errors.New("request body is too large")
+	ErrDeleteConflict    = errors.New("product kept changing while it was being deleted")
 )
 
+// maxDeleteAttempts is how many times DeleteProduct starts over when the
+// product changes while it is being deleted.
+const maxDeleteAttempts = 3
+
 type Products struct {
-	store types.Store
+	store         types.Store
+	now           func() time.Time
+	maxBodySize   int
+	ignoredFields []string
+}
+
+type ProductsOption func(*Products)
+
+// WithMaxBodySize sets the largest request body in bytes PutProduct accepts.
+func WithMaxBodySize(size int) ProductsOption {
+	return func(d *Products) {
+		d.maxBodySize = size
+	}
 }
 
-func NewProductsDomain(s types.Store) *Products {
-	return &Products{
-		store: s,
+// WithIgnoredFields sets the product fields whose changes alone don't make
+// PutProduct write the product, when the store is a types.ConditionalStore.
+// It defaults to types.DefaultIgnoredFields.
+func WithIgnoredFields(fields ...string) ProductsOption {
+	return func(d *Products) {
+		d.ignoredFields = fields
 	}
 }
 
+func NewProductsDomain(s types.Store, opts ...ProductsOption) *Products {
+	d := &Products{
+		store:         s,
+		now:           time.Now,
+		maxBodySize:   DefaultMaxBodySize,
+		ignoredFields: types.DefaultIgnoredFields,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
 func (d *Products) GetProduct(ctx context.Context, id string) (*types.Product, error) {
 	product, err := d.store.Get(ctx, id)
 	if err != nil {
@@ -48,28 +93,133 @@ func (d *Products) AllProducts(ctx context.Context, next *string) (types.Product
 }
 
 func (d *Products) PutProduct(ctx context.Context, id string, body []byte) (*types.Product, error) {
+	if len(body) > d.maxBodySize {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrBodyTooLarge, len(body), d.maxBodySize)
+	}
+
 	product := types.Product{}
-	if err := json.Unmarshal(body, &product); err != nil {
-		return nil, fmt.Errorf("%w", ErrJsonUnmarshal)
+	if err := decodeStrict(body, &product); err != nil {
+		return nil, fmt.Errorf("%w", err)
 	}
 
 	if product.Id != id {
 		return nil, fmt.Errorf("%w", ErrProductIdMismatch)
 	}
 
-	err := d.store.Put(ctx, product)
+	d.stamp(ctx, &product)
+
+	conditionalStore, ok := d.store.(types.ConditionalStore)
+	if !ok {
+		if err := d.store.Put(ctx, product); err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		return &product, nil
+	}
+
+	written, err := conditionalStore.PutIfChanged(ctx, product, d.ignoredFields)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	if written {
+		return &product, nil
+	}
+
+	// The product is unchanged, so it keeps the author and time of the change
+	// that actually made it.
+	stored, err := d.store.Get(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("%w", err)
 	}
 
-	return &product, nil
+	if stored == nil {
+		return &product, nil
+	}
+
+	return stored, nil
 }
 
+// DeleteProduct first overwrites the product with a tombstone recording who
+// deletes it, so that the stream REMOVE record carries the actor in its old
+// image, and then deletes it. When the store is a types.VersionedStore, both
+// writes are conditioned on the product being unchanged since it was read, and
+// the deletion starts over from a fresh read when it changed.
 func (d *Products) DeleteProduct(ctx context.Context, id string) error {
-	err := d.store.Delete(ctx, id)
+	versionedStore, ok := d.store.(types.VersionedStore)
+	if !ok {
+		return d.deleteProduct(ctx, id)
+	}
+
+	for attempt := 0; attempt < maxDeleteAttempts; attempt++ {
+		deleted, err := d.deleteUnmodifiedProduct(ctx, versionedStore, id)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		if deleted {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrDeleteConflict, id)
+}
+
+// deleteUnmodifiedProduct tells whether the product could be deleted before
+// anything else changed it.
+func (d *Products) deleteUnmodifiedProduct(ctx context.Context, s types.VersionedStore, id string) (bool, error) {
+	product, err := s.Get(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	// Get hides tombstones, so this only clears one left by a deletion that
+	// failed halfway.
+	if product == nil {
+		return true, s.Delete(ctx, id)
+	}
+
+	updatedAt := product.UpdatedAt
+	product.Deleted = true
+	d.stamp(ctx, product)
+
+	if written, err := s.PutIfUnmodified(ctx, *product, updatedAt); err != nil || !written {
+		return false, err
+	}
+
+	return s.DeleteIfUnmodified(ctx, id, product.UpdatedAt)
+}
+
+func (d *Products) deleteProduct(ctx context.Context, id string) error {
+	product, err := d.store.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	if product != nil {
+		product.Deleted = true
+		d.stamp(ctx, product)
+
+		if err := d.store.Put(ctx, *product); err != nil {
+			return fmt.Errorf("%w", err)
+		}
+	}
+
+	err = d.store.Delete(ctx, id)
 	if err != nil {
 		return fmt.Errorf("%w", err)
 	}
 
 	return nil
 }
+
+// stamp records the principal acting in ctx as the author of the change.
+func (d *Products) stamp(ctx context.Context, product *types.Product) {
+	product.UpdatedBy = AnonymousActor
+	if principal, ok := auth.FromContext(ctx); ok && principal.Subject != "" {
+		product.UpdatedBy = principal.Subject
+	}
+
+	updatedAt := d.now().UTC()
+	product.UpdatedAt = &updatedAt
+}
@@ -2,26 +2,260 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/aws-samples/serverless-go-demo/cloudevents"
+	"github.com/aws-samples/serverless-go-demo/schemas"
 	"github.com/aws-samples/serverless-go-demo/types"
 )
 
+const EventSource = "serverless-go-demo"
+
+// FailureCodeInvalidChange reports changes that can't be turned into events.
+const FailureCodeInvalidChange = "InvalidChange"
+
+// FailureCodeSchemaViolation reports changes whose events don't conform to
+// the schema of their detail type.
+const FailureCodeSchemaViolation = "SchemaViolation"
+
+var ErrSchemaViolation = errors.New("event data does not conform to its schema")
+
+// eventSchemas are the schemas events are validated against before they are
+// published.
+var eventSchemas = schemas.MustLoad()
+
+// Detail types of the published events. Updates are published as
+// ProductChanged, followed by PriceChanged or ProductRenamed when those fields
+// changed.
+const (
+	DetailTypeProductCreated = "ProductCreated"
+	DetailTypeProductChanged = "ProductChanged"
+	DetailTypeProductDeleted = "ProductDeleted"
+	DetailTypePriceChanged   = "PriceChanged"
+	DetailTypeProductRenamed = "ProductRenamed"
+)
+
+// Deprecated detail types, under which the first releases published updates
+// and deletions. ProductUpdated is published next to every ProductChanged and
+// ProductDelected next to every ProductDeleted, with the same data, until
+// the rules matching them move to the new names.
+const (
+	DetailTypeProductUpdated  = "ProductUpdated"
+	DetailTypeProductDelected = "ProductDelected"
+)
+
+var cloudEventTypes = map[string]string{
+	DetailTypeProductCreated:  "serverless-go-demo.product.created",
+	DetailTypeProductChanged:  "serverless-go-demo.product.changed",
+	DetailTypeProductDeleted:  "serverless-go-demo.product.deleted",
+	DetailTypePriceChanged:    "serverless-go-demo.product.price-changed",
+	DetailTypeProductRenamed:  "serverless-go-demo.product.renamed",
+	DetailTypeProductUpdated:  "serverless-go-demo.product.updated",
+	DetailTypeProductDelected: "serverless-go-demo.product.delected",
+}
+
 type ProductsStream struct {
-	bus types.Bus
+	bus           types.Bus
+	ignoredFields []string
+}
+
+type ProductsStreamOption func(*ProductsStream)
+
+// WithStreamIgnoredFields sets the product fields whose changes alone don't
+// make an update worth publishing. It defaults to types.DefaultIgnoredFields.
+func WithStreamIgnoredFields(fields ...string) ProductsStreamOption {
+	return func(p *ProductsStream) {
+		p.ignoredFields = fields
+	}
 }
 
-func NewProductsStream(b types.Bus) *ProductsStream {
-	return &ProductsStream{
-		bus: b,
+func NewProductsStream(b types.Bus, opts ...ProductsStreamOption) *ProductsStream {
+	p := &ProductsStream{
+		bus:           b,
+		ignoredFields: types.DefaultIgnoredFields,
+	}
+
+	for _, opt := range opts {
+		opt(p)
 	}
+
+	return p
 }
 
-func (p *ProductsStream) Publish(ctx context.Context, events []types.Event) ([]types.FailedEvent, error) {
+// Publish turns product changes into events and puts them on the bus.
+// Updates that only mark a product as deleted are skipped, as the deletion
+// that follows is published with the same product, and so are updates that
+// only changed ignored fields. Changes that can't be
+// turned into events are returned as failed events with the
+// FailureCodeInvalidChange code, or FailureCodeSchemaViolation when one of
+// their events doesn't conform to its schema, and with the change id as their
+// resource. None of the events of such a change are published.
+func (p *ProductsStream) Publish(ctx context.Context, changes []types.ProductChange) ([]types.FailedEvent, error) {
+	events := []types.Event{}
+	invalidChanges := []types.FailedEvent{}
+	for _, change := range changes {
+		changeEvents, err := p.eventsFromChange(change)
+		if err != nil {
+			code := FailureCodeInvalidChange
+			if errors.Is(err, ErrSchemaViolation) {
+				code = FailureCodeSchemaViolation
+			}
+
+			invalidChanges = append(invalidChanges, types.FailedEvent{
+				Event:          types.Event{Id: change.Id, Source: EventSource, Resources: []string{change.Id}},
+				FailureCode:    code,
+				FailureMessage: err.Error(),
+			})
+			continue
+		}
+
+		events = append(events, changeEvents...)
+	}
+
+	if len(events) == 0 {
+		return invalidChanges, nil
+	}
+
 	failedEvents, err := p.bus.Put(ctx, events)
 	if err != nil {
-		return failedEvents, fmt.Errorf("%w", err)
+		return append(invalidChanges, failedEvents...), fmt.Errorf("%w", err)
+	}
+
+	return append(invalidChanges, failedEvents...), nil
+}
+
+func (p *ProductsStream) eventsFromChange(change types.ProductChange) ([]types.Event, error) {
+	switch {
+	case change.Type == types.ProductCreated && change.After != nil:
+		event, err := newEvent(change, DetailTypeProductCreated, change.After.Id, change.After)
+		return []types.Event{event}, err
+
+	case change.Type == types.ProductDeleted && change.Before != nil:
+		event, err := newEvent(change, DetailTypeProductDeleted, change.Before.Id, change.Before)
+		if err != nil {
+			return nil, err
+		}
+
+		legacyEvent, err := newEvent(change, DetailTypeProductDelected, change.Before.Id, change.Before)
+		return []types.Event{event, legacyEvent}, err
+
+	case change.Type == types.ProductUpdated && change.Before != nil && change.After != nil:
+		if change.After.Deleted || types.Unchanged(*change.Before, *change.After, p.ignoredFields) {
+			return nil, nil
+		}
+
+		return updateEvents(change, *change.Before, *change.After)
+	}
+
+	return nil, fmt.Errorf("incomplete %s change '%s'", change.Type, change.Id)
+}
+
+func updateEvents(change types.ProductChange, before types.Product, after types.Product) ([]types.Event, error) {
+	detail := types.ProductChangedDetail{
+		Before:        before,
+		After:         after,
+		ChangedFields: types.ChangedFields(before, after),
+	}
+
+	events := []types.Event{}
+	for _, detailType := range []string{DetailTypeProductChanged, DetailTypeProductUpdated} {
+		event, err := newEvent(change, detailType, after.Id, detail)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if before.Price != after.Price {
+		event, err := newEvent(change, DetailTypePriceChanged, after.Id, types.PriceChangedDetail{
+			Id:   after.Id,
+			From: before.Price,
+			To:   after.Price,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if before.Name != after.Name {
+		event, err := newEvent(change, DetailTypeProductRenamed, after.Id, types.ProductRenamedDetail{
+			Id:   after.Id,
+			From: before.Name,
+			To:   after.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// newEvent wraps data in a CloudEvent. The main event of a change gets the
+// change id, and the other events derived from it ids made from the change id
+// and their detail type, so that they stay unique and stable across retries.
+func newEvent(change types.ProductChange, detailType string, subject string, data interface{}) (types.Event, error) {
+	id := change.Id
+	switch detailType {
+	case DetailTypeProductCreated, DetailTypeProductChanged, DetailTypeProductDeleted:
+	default:
+		id = change.Id + "#" + detailType
+	}
+
+	cloudEvent, err := cloudevents.New(id, EventSource, cloudEventTypes[detailType], data)
+	if err != nil {
+		return types.Event{}, err
+	}
+
+	if err := validateData(&cloudEvent, detailType); err != nil {
+		return types.Event{}, err
+	}
+
+	cloudEvent.Subject = subject
+	if !change.Time.IsZero() {
+		eventTime := change.Time.UTC()
+		cloudEvent.Time = &eventTime
+	}
+
+	detail, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return types.Event{}, fmt.Errorf("cannot marshal cloud event: %w", err)
 	}
 
-	return failedEvents, nil
+	return types.Event{
+		Id:         id,
+		Subject:    subject,
+		Source:     EventSource,
+		Detail:     string(detail),
+		DetailType: detailType,
+		Resources:  []string{change.Id},
+	}, nil
+}
+
+// validateData checks the data of an event against the latest schema of its
+// detail type, and records that schema in the event.
+func validateData(cloudEvent *cloudevents.Event, detailType string) error {
+	schema, version, ok := eventSchemas.Latest(detailType)
+	if !ok {
+		return fmt.Errorf("%w: no schema for %s events", ErrSchemaViolation, detailType)
+	}
+
+	if errs := schema.ValidateJSON(cloudEvent.Data); len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+
+		return fmt.Errorf("%w: %s version %d: %s", ErrSchemaViolation, detailType, version, strings.Join(messages, "; "))
+	}
+
+	cloudEvent.DataSchema = schema.Id
+	cloudEvent.SchemaVersion = version
+
+	return nil
 }
@@ -0,0 +1,161 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/bus"
+	"github.com/aws-samples/serverless-go-demo/cloudevents"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+func TestPublishUpdateEvents(t *testing.T) {
+	eventBus := bus.NewMemoryBus()
+	before := types.Product{Id: "iXR", Name: "iPhone XML", Price: 1}
+	after := types.Product{Id: "iXR", Name: "iPhone YAML", Price: 2, UpdatedBy: "alice"}
+
+	_, err := NewProductsStream(eventBus).Publish(context.Background(), []types.ProductChange{{
+		Id:     "1",
+		Type:   types.ProductUpdated,
+		Time:   time.Now(),
+		Before: &before,
+		After:  &after,
+	}})
+	if err != nil {
+		t.Fatalf("Publish returned an error: %s", err)
+	}
+
+	events := eventBus.Events()
+
+	detailTypes := []string{}
+	for _, event := range events {
+		detailTypes = append(detailTypes, event.DetailType)
+	}
+	expected := []string{DetailTypeProductChanged, DetailTypeProductUpdated, DetailTypePriceChanged, DetailTypeProductRenamed}
+	if !reflect.DeepEqual(detailTypes, expected) {
+		t.Fatalf("Got detail types %v, expected %v", detailTypes, expected)
+	}
+
+	// The deprecated ProductUpdated event carries the same data under an id
+	// of its own.
+	if events[1].Id != "1#"+DetailTypeProductUpdated || !strings.Contains(events[1].Detail, `"changedFields":["name","price","updatedBy"]`) {
+		t.Errorf("Got unexpected ProductUpdated event %+v", events[1])
+	}
+
+	changed := types.ProductChangedDetail{}
+	cloudEvent, _ := cloudevents.Decode([]byte(events[0].Detail))
+	if err := cloudEvent.DataAs(&changed); err != nil {
+		t.Fatalf("DataAs returned an error: %s", err)
+	}
+	if !reflect.DeepEqual(changed.ChangedFields, []string{"name", "price", "updatedBy"}) || changed.Before.Price != 1 || changed.After.Price != 2 {
+		t.Errorf("Got unexpected ProductChanged data %+v", changed)
+	}
+
+	priceChanged := types.PriceChangedDetail{}
+	cloudEvent, _ = cloudevents.Decode([]byte(events[2].Detail))
+	cloudEvent.DataAs(&priceChanged)
+	if priceChanged.From != 1 || priceChanged.To != 2 || cloudEvent.Id == events[0].Id {
+		t.Errorf("Got unexpected PriceChanged event %+v with data %+v", cloudEvent, priceChanged)
+	}
+}
+
+func TestPublishSkipsUnchangedUpdates(t *testing.T) {
+	eventBus := bus.NewMemoryBus()
+	updatedAt := time.Now()
+	before := types.Product{Id: "iXR", Name: "iPhone XML", Price: 1}
+	touched := types.Product{Id: "iXR", Name: "iPhone XML", Price: 1, UpdatedBy: "alice", UpdatedAt: &updatedAt}
+
+	_, err := NewProductsStream(eventBus).Publish(context.Background(), []types.ProductChange{
+		{Id: "1", Type: types.ProductUpdated, Before: &before, After: &touched},
+	})
+	if err != nil {
+		t.Fatalf("Publish returned an error: %s", err)
+	}
+
+	if events := eventBus.Events(); len(events) != 0 {
+		t.Errorf("Got events %+v, expected none", events)
+	}
+
+	NewProductsStream(eventBus, WithStreamIgnoredFields()).Publish(context.Background(), []types.ProductChange{
+		{Id: "1", Type: types.ProductUpdated, Before: &before, After: &touched},
+	})
+
+	if events := eventBus.Events(); len(events) != 2 || events[0].DetailType != DetailTypeProductChanged {
+		t.Errorf("Got events %+v, expected a ProductChanged event without ignored fields", events)
+	}
+}
+
+func TestPublishSkipsTombstones(t *testing.T) {
+	eventBus := bus.NewMemoryBus()
+	before := types.Product{Id: "iXR", Name: "iPhone XML"}
+	tombstone := types.Product{Id: "iXR", Name: "iPhone XML", Deleted: true}
+
+	_, err := NewProductsStream(eventBus).Publish(context.Background(), []types.ProductChange{
+		{Id: "1", Type: types.ProductUpdated, Before: &before, After: &tombstone},
+		{Id: "2", Type: types.ProductDeleted, Before: &tombstone},
+	})
+	if err != nil {
+		t.Fatalf("Publish returned an error: %s", err)
+	}
+
+	events := eventBus.Events()
+
+	if len(events) != 2 || events[0].DetailType != DetailTypeProductDeleted || events[1].DetailType != DetailTypeProductDelected || events[0].Subject != "iXR" {
+		t.Errorf("Got unexpected events %+v", events)
+	}
+}
+
+func TestPublishReturnsRejectedEvents(t *testing.T) {
+	eventBus := bus.NewMemoryBus(bus.WithFault(bus.Fault{Every: 2, Code: "ThrottlingException"}))
+	first := types.Product{Id: "iXR", Name: "iPhone XML"}
+	second := types.Product{Id: "iXS", Name: "iPhone XS"}
+
+	failedEvents, err := NewProductsStream(eventBus).Publish(context.Background(), []types.ProductChange{
+		{Id: "1", Type: types.ProductCreated, After: &first},
+		{Id: "2", Type: types.ProductCreated, After: &second},
+	})
+	if err != nil {
+		t.Fatalf("Publish returned an error: %s", err)
+	}
+
+	if len(failedEvents) != 1 || failedEvents[0].Subject != "iXS" || failedEvents[0].FailureCode != "ThrottlingException" {
+		t.Errorf("Got failed events %+v, expected the creation of iXS", failedEvents)
+	}
+	if events := eventBus.Events(); len(events) != 1 || events[0].Subject != "iXR" {
+		t.Errorf("Got events %+v, expected the creation of iXR", events)
+	}
+}
+
+func TestPublishRecordsSchema(t *testing.T) {
+	eventBus := bus.NewMemoryBus()
+	product := types.Product{Id: "iXR", Name: "iPhone XML"}
+
+	NewProductsStream(eventBus).Publish(context.Background(), []types.ProductChange{
+		{Id: "1", Type: types.ProductCreated, After: &product},
+	})
+
+	events := eventBus.Events()
+	if len(events) != 1 {
+		t.Fatalf("Got events %+v, expected one", events)
+	}
+
+	cloudEvent, _ := cloudevents.Decode([]byte(events[0].Detail))
+	if cloudEvent.SchemaVersion != 2 || !strings.HasSuffix(cloudEvent.DataSchema, "/ProductCreated/v2.json") {
+		t.Errorf("Got schema %s version %d, expected version 2 of the ProductCreated schema", cloudEvent.DataSchema, cloudEvent.SchemaVersion)
+	}
+}
+
+func TestValidateDataRejectsViolations(t *testing.T) {
+	cloudEvent, _ := cloudevents.New("1", EventSource, "test", map[string]interface{}{"id": "iXR", "from": "cheap"})
+
+	if err := validateData(&cloudEvent, DetailTypePriceChanged); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("Got error %v, expected a schema violation", err)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/aws-samples/serverless-go-demo/auth"
 	"github.com/aws-samples/serverless-go-demo/store"
 	"github.com/aws-samples/serverless-go-demo/types"
 	"github.com/aws-samples/serverless-go-demo/types/mocks"
@@ -168,3 +170,158 @@ func TestAllProducts(t *testing.T) {
 		}
 	})
 }
+
+func TestPutProductRecordsPrincipal(t *testing.T) {
+	ctx := auth.NewContext(context.Background(), auth.Principal{Subject: "alice"})
+	domain := NewProductsDomain(store.NewMemoryStore())
+
+	product, err := domain.PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1, "updatedBy": "mallory"}`))
+	if err != nil {
+		t.Fatalf("PutProduct returned an error: %s", err)
+	}
+
+	if product.UpdatedBy != "alice" || product.UpdatedAt == nil {
+		t.Errorf("Got updatedBy %q and updatedAt %v, expected the principal and a time", product.UpdatedBy, product.UpdatedAt)
+	}
+
+	product, _ = domain.PutProduct(context.Background(), "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 2}`))
+	if product.UpdatedBy != AnonymousActor {
+		t.Errorf("Got updatedBy %q without a principal", product.UpdatedBy)
+	}
+}
+
+func TestPutProductSkipsUnchangedProducts(t *testing.T) {
+	memoryStore := store.NewMemoryStore(store.WithOutbox())
+	domain := NewProductsDomain(memoryStore)
+	body := []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1}`)
+
+	first, err := domain.PutProduct(context.Background(), "iXR", body)
+	if err != nil {
+		t.Fatalf("PutProduct returned an error: %s", err)
+	}
+
+	domain.now = func() time.Time { return first.UpdatedAt.Add(time.Minute) }
+	ctx := auth.NewContext(context.Background(), auth.Principal{Subject: "alice"})
+
+	second, err := domain.PutProduct(ctx, "iXR", body)
+	if err != nil {
+		t.Fatalf("PutProduct returned an error: %s", err)
+	}
+
+	if second.UpdatedBy != AnonymousActor || !second.UpdatedAt.Equal(*first.UpdatedAt) {
+		t.Errorf("Got product %+v, expected the stored one", second)
+	}
+
+	changes, _ := memoryStore.Pending(context.Background(), 10)
+	if len(changes) != 1 {
+		t.Errorf("Got %d changes, expected the creation only", len(changes))
+	}
+
+	third, _ := domain.PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 2}`))
+	if third.UpdatedBy != "alice" || third.Price != 2 {
+		t.Errorf("Got product %+v, expected the new price to be written", third)
+	}
+}
+
+func TestDeleteProductWritesTombstone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := auth.NewContext(context.Background(), auth.Principal{Subject: "alice"})
+
+	store := mocks.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			Get(ctx, gomock.Eq("iXR")).
+			Return(&types.Product{Id: "iXR", Name: "iPhone XML"}, nil),
+		store.EXPECT().
+			Put(ctx, gomock.Any()).
+			Do(func(ctx context.Context, product types.Product) {
+				if !product.Deleted || product.UpdatedBy != "alice" || product.Name != "iPhone XML" {
+					t.Errorf("Got unexpected tombstone %+v", product)
+				}
+			}),
+		store.EXPECT().
+			Delete(ctx, gomock.Eq("iXR")),
+	)
+
+	if err := NewProductsDomain(store).DeleteProduct(ctx, "iXR"); err != nil {
+		t.Errorf("DeleteProduct returned an error: %s", err)
+	}
+}
+
+// racingStore runs race before each of its conditional writes, like a
+// concurrent request landing between the steps of a deletion.
+type racingStore struct {
+	*store.MemoryStore
+	racePut    func()
+	raceDelete func()
+}
+
+func (s racingStore) PutIfUnmodified(ctx context.Context, product types.Product, updatedAt *time.Time) (bool, error) {
+	if s.racePut != nil {
+		s.racePut()
+	}
+	return s.MemoryStore.PutIfUnmodified(ctx, product, updatedAt)
+}
+
+func (s racingStore) DeleteIfUnmodified(ctx context.Context, id string, updatedAt *time.Time) (bool, error) {
+	if s.raceDelete != nil {
+		s.raceDelete()
+	}
+	return s.MemoryStore.DeleteIfUnmodified(ctx, id, updatedAt)
+}
+
+func TestDeleteProductRereadsProductChangedConcurrently(t *testing.T) {
+	memoryStore := store.NewMemoryStore(store.WithOutbox())
+	ctx := context.Background()
+	NewProductsDomain(memoryStore).PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1}`))
+
+	raced := false
+	racing := racingStore{MemoryStore: memoryStore}
+	racing.racePut = func() {
+		if !raced {
+			raced = true
+			changedAt := time.Now().Add(-time.Hour)
+			memoryStore.Put(ctx, types.Product{Id: "iXR", Name: "iPhone XML", Price: 2, UpdatedBy: "bob", UpdatedAt: &changedAt})
+		}
+	}
+
+	if err := NewProductsDomain(racing).DeleteProduct(ctx, "iXR"); err != nil {
+		t.Fatalf("DeleteProduct returned an error: %s", err)
+	}
+
+	if product, _ := memoryStore.Get(ctx, "iXR"); product != nil {
+		t.Errorf("Got product %+v, expected it to be deleted", product)
+	}
+
+	// The stale tombstone was rejected, so the one written describes the
+	// concurrent change.
+	changes, _ := memoryStore.Pending(ctx, DefaultOutboxBatchSize)
+	if len(changes) != 4 {
+		t.Fatalf("Got changes %+v, expected the creation, the concurrent change, the tombstone and the deletion", changes)
+	}
+	tombstone := changes[2]
+	if tombstone.Before == nil || tombstone.Before.Price != 2 || tombstone.After == nil || !tombstone.After.Deleted || tombstone.After.Price != 2 {
+		t.Errorf("Got tombstone change %+v, expected it to follow the concurrent change", tombstone)
+	}
+}
+
+func TestDeleteProductGivesUpWhenProductKeepsChanging(t *testing.T) {
+	memoryStore := store.NewMemoryStore()
+	ctx := context.Background()
+	NewProductsDomain(memoryStore).PutProduct(ctx, "iXR", []byte(`{"id": "iXR", "name": "iPhone XML", "price": 1}`))
+
+	racing := racingStore{MemoryStore: memoryStore}
+	racing.raceDelete = func() {
+		changedAt := time.Now()
+		memoryStore.Put(ctx, types.Product{Id: "iXR", Name: "iPhone XML", Price: 2, UpdatedBy: "bob", UpdatedAt: &changedAt})
+	}
+
+	err := NewProductsDomain(racing).DeleteProduct(ctx, "iXR")
+	if !errors.Is(err, ErrDeleteConflict) {
+		t.Fatalf("Got error %v, expected ErrDeleteConflict", err)
+	}
+
+	if product, _ := memoryStore.Get(ctx, "iXR"); product == nil || product.UpdatedBy != "bob" {
+		t.Errorf("Got product %+v, expected the concurrent write to be kept", product)
+	}
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// maxBucketUpdateAttempts bounds the optimistic locking retries when many
+// requests from the same client update its bucket at once.
+const maxBucketUpdateAttempts = 3
+
+type RateLimitDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, when it isn't.
+	RetryAfter time.Duration
+}
+
+// RateLimiter implements token buckets on top of a RateLimitStore. Each bucket
+// holds up to Burst tokens and is refilled at Requests per Period.
+type RateLimiter struct {
+	store types.RateLimitStore
+	now   func() time.Time
+}
+
+func NewRateLimiter(s types.RateLimitStore) *RateLimiter {
+	return &RateLimiter{
+		store: s,
+		now:   time.Now,
+	}
+}
+
+func (r *RateLimiter) Allow(ctx context.Context, key string, limit types.RateLimit) (RateLimitDecision, error) {
+	if limit.Requests <= 0 || limit.Period <= 0 {
+		return RateLimitDecision{Allowed: true}, nil
+	}
+
+	capacity := float64(limit.Burst)
+	if limit.Burst <= 0 {
+		capacity = float64(limit.Requests)
+	}
+	refillPerSecond := float64(limit.Requests) / limit.Period.Seconds()
+
+	for attempt := 0; attempt < maxBucketUpdateAttempts; attempt++ {
+		now := r.now()
+
+		bucket, err := r.store.Get(ctx, key)
+		if err != nil {
+			return RateLimitDecision{}, fmt.Errorf("%w", err)
+		}
+
+		expectedVersion := int64(0)
+		tokens := capacity
+		if bucket != nil {
+			expectedVersion = bucket.Version
+			elapsed := now.Sub(time.Unix(0, bucket.UpdatedAt)).Seconds()
+			tokens = math.Min(capacity, bucket.Tokens+math.Max(0, elapsed)*refillPerSecond)
+		}
+
+		decision := RateLimitDecision{
+			Allowed: tokens >= 1,
+			Limit:   int(capacity),
+		}
+
+		if decision.Allowed {
+			tokens--
+		} else {
+			decision.RetryAfter = secondsToDuration((1 - tokens) / refillPerSecond)
+		}
+
+		decision.Remaining = int(math.Floor(tokens))
+		decision.Reset = secondsToDuration((capacity - tokens) / refillPerSecond)
+
+		err = r.store.Put(ctx, types.TokenBucket{
+			Key:       key,
+			Tokens:    tokens,
+			UpdatedAt: now.UnixNano(),
+			Version:   expectedVersion + 1,
+			// Full buckets don't need to be stored, so they expire once refilled.
+			ExpiresAt: now.Add(decision.Reset).Add(time.Minute).Unix(),
+		}, expectedVersion)
+
+		if errors.Is(err, types.ErrBucketConflict) {
+			continue
+		}
+		if err != nil {
+			return RateLimitDecision{}, fmt.Errorf("%w", err)
+		}
+
+		return decision, nil
+	}
+
+	return RateLimitDecision{}, fmt.Errorf("%w after %d attempts", types.ErrBucketConflict, maxBucketUpdateAttempts)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
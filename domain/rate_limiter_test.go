@@ -0,0 +1,48 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/store"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+func TestRateLimiterRefillsBucket(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := NewRateLimiter(store.NewMemoryRateLimitStore())
+	limiter.now = func() time.Time { return now }
+	limit := types.RateLimit{Requests: 1, Period: time.Second, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		decision, err := limiter.Allow(ctx, "client", limit)
+		if err != nil {
+			t.Fatalf("Allow returned an error: %s", err)
+		}
+		if !decision.Allowed || decision.Remaining != 1-i {
+			t.Fatalf("Request %d got decision %+v, expected it allowed with %d remaining", i, decision, 1-i)
+		}
+	}
+
+	decision, err := limiter.Allow(ctx, "client", limit)
+	if err != nil {
+		t.Fatalf("Allow returned an error: %s", err)
+	}
+	if decision.Allowed || decision.RetryAfter != time.Second {
+		t.Fatalf("Got decision %+v once the burst is used, expected a denial for 1s", decision)
+	}
+
+	if decision, _ := limiter.Allow(ctx, "other-client", limit); !decision.Allowed {
+		t.Errorf("Another client was limited")
+	}
+
+	now = now.Add(time.Second)
+	if decision, _ := limiter.Allow(ctx, "client", limit); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("Got decision %+v after a refill, expected it allowed with 0 remaining", decision)
+	}
+}
@@ -0,0 +1,237 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// DefaultSearchPageSize is how many products a page of search results holds
+// unless configured with WithSearchPageSize.
+const DefaultSearchPageSize = 20
+
+// MaxSearchTerms is how many distinct terms a query can have, as each of
+// them is read from the index on its own.
+const MaxSearchTerms = 10
+
+// Weights of the terms found in each product field. A term found in several
+// fields adds up their weights.
+const (
+	nameWeight  = 3
+	tagWeight   = 2
+	priceWeight = 1
+)
+
+// priceBucketPrefix starts the terms of price buckets, such as "price:10-50",
+// which queries can use as they are.
+const priceBucketPrefix = "price:"
+
+// priceBuckets are the upper bounds of the price buckets, the last one having
+// no upper bound.
+var priceBuckets = []float64{10, 50, 100, 500, 1000}
+
+var (
+	ErrEmptyQuery        = errors.New("search query has no terms")
+	ErrTooManyTerms      = errors.New("search query has too many terms")
+	ErrInvalidSearchNext = errors.New("invalid search pagination token")
+)
+
+type Search struct {
+	index    types.SearchIndex
+	store    types.Store
+	pageSize int
+}
+
+type SearchOption func(*Search)
+
+// WithSearchPageSize sets how many products a page of search results holds.
+func WithSearchPageSize(size int) SearchOption {
+	return func(s *Search) {
+		s.pageSize = size
+	}
+}
+
+func NewSearch(i types.SearchIndex, s types.Store, opts ...SearchOption) *Search {
+	search := &Search{
+		index:    i,
+		store:    s,
+		pageSize: DefaultSearchPageSize,
+	}
+
+	for _, opt := range opts {
+		opt(search)
+	}
+
+	return search
+}
+
+// Search returns the products matching any term of the query, those
+// matching the most terms first, then those where the terms weigh the most.
+// Results are ranked again for every page, and next is the offset of the
+// following page. Products the index still lists but the store no longer
+// has are left out, so pages can be short.
+func (s *Search) Search(ctx context.Context, query string, next *string) (types.ProductRange, error) {
+	productRange := types.ProductRange{
+		Products: []types.Product{},
+	}
+
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return productRange, fmt.Errorf("%w", ErrEmptyQuery)
+	}
+
+	if len(terms) > MaxSearchTerms {
+		return productRange, fmt.Errorf("%w: %d, the limit is %d", ErrTooManyTerms, len(terms), MaxSearchTerms)
+	}
+
+	offset := 0
+	if next != nil && strings.TrimSpace(*next) != "" {
+		var err error
+		if offset, err = strconv.Atoi(*next); err != nil || offset < 0 {
+			return productRange, fmt.Errorf("%w: '%s'", ErrInvalidSearchNext, *next)
+		}
+	}
+
+	postings, err := s.index.Postings(ctx, terms)
+	if err != nil {
+		return productRange, fmt.Errorf("%w", err)
+	}
+
+	ids := rank(postings)
+	if offset >= len(ids) {
+		return productRange, nil
+	}
+
+	end := offset + s.pageSize
+	if end < len(ids) {
+		nextOffset := strconv.Itoa(end)
+		productRange.Next = &nextOffset
+	} else {
+		end = len(ids)
+	}
+
+	for _, id := range ids[offset:end] {
+		product, err := s.store.Get(ctx, id)
+		if err != nil {
+			return productRange, fmt.Errorf("%w", err)
+		}
+
+		if product != nil {
+			productRange.Products = append(productRange.Products, *product)
+		}
+	}
+
+	return productRange, nil
+}
+
+// rank orders the ids of the postings by the number of terms they match,
+// then by the sum of their weights, then by id.
+func rank(postings []types.SearchPosting) []string {
+	matches := map[string]int{}
+	scores := map[string]float64{}
+
+	for _, posting := range postings {
+		matches[posting.Id]++
+		scores[posting.Id] += posting.Weight
+	}
+
+	ids := make([]string, 0, len(matches))
+	for id := range matches {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i], ids[j]
+		if matches[a] != matches[b] {
+			return matches[a] > matches[b]
+		}
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return a < b
+	})
+
+	return ids
+}
+
+// searchTerms returns the terms of the product with their weights: the
+// words of its name and tags, and its price bucket.
+func searchTerms(product types.Product) map[string]float64 {
+	terms := map[string]float64{}
+
+	for _, token := range uniqueTokens(product.Name) {
+		terms[token] += nameWeight
+	}
+
+	for _, token := range uniqueTokens(strings.Join(product.Tags, " ")) {
+		terms[token] += tagWeight
+	}
+
+	terms[priceBucket(product.Price)] += priceWeight
+
+	return terms
+}
+
+// queryTerms returns the terms of a query, in order and without duplicates.
+// Words starting with the price bucket prefix are kept whole.
+func queryTerms(query string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+
+	for _, field := range strings.Fields(strings.ToLower(query)) {
+		tokens := []string{field}
+		if !strings.HasPrefix(field, priceBucketPrefix) {
+			tokens = tokenize(field)
+		}
+
+		for _, token := range tokens {
+			if !seen[token] {
+				seen[token] = true
+				terms = append(terms, token)
+			}
+		}
+	}
+
+	return terms
+}
+
+// tokenize splits text into lower case words of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func uniqueTokens(text string) []string {
+	tokens := []string{}
+	seen := map[string]bool{}
+
+	for _, token := range tokenize(text) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+// priceBucket returns the term of the price bucket, such as "price:10-50"
+// for prices from 10 up to 50, or "price:1000+" for the last one.
+func priceBucket(price float64) string {
+	lower := 0.0
+	for _, upper := range priceBuckets {
+		if price < upper {
+			return fmt.Sprintf("%s%g-%g", priceBucketPrefix, lower, upper)
+		}
+		lower = upper
+	}
+
+	return fmt.Sprintf("%s%g+", priceBucketPrefix, lower)
+}
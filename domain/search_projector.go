@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// SearchProjector keeps the search index up to date with the product
+// changes, and rebuilds it from the products when it is lost or was built
+// with other terms.
+type SearchProjector struct {
+	index types.SearchIndex
+}
+
+func NewSearchProjector(i types.SearchIndex) *SearchProjector {
+	return &SearchProjector{
+		index: i,
+	}
+}
+
+// projection is the net change of a product over a batch of changes: the
+// product as it was indexed before the first change, and as it is after the
+// last one, nil when it no longer exists.
+type projection struct {
+	before *types.Product
+	after  *types.Product
+}
+
+// Project indexes the products as they are after the changes, and removes
+// the terms they no longer have. Several changes of the same product are
+// folded into one, so that a posting is never both put and removed. Changes
+// can be projected again, as when a batch is retried.
+func (p *SearchProjector) Project(ctx context.Context, changes []types.ProductChange) error {
+	projections := map[string]*projection{}
+	ids := []string{}
+
+	for _, change := range changes {
+		id := productId(change)
+		if id == "" {
+			continue
+		}
+
+		after := change.After
+		if after != nil && after.Deleted {
+			after = nil
+		}
+
+		if projection, ok := projections[id]; ok {
+			projection.after = after
+			continue
+		}
+
+		projections[id] = &projection{before: change.Before, after: after}
+		ids = append(ids, id)
+	}
+
+	put := []types.SearchPosting{}
+	remove := []types.SearchPosting{}
+
+	for _, id := range ids {
+		projection := projections[id]
+
+		afterTerms := map[string]float64{}
+		if projection.after != nil {
+			afterTerms = searchTerms(*projection.after)
+		}
+
+		if projection.before != nil {
+			for term := range searchTerms(*projection.before) {
+				if _, ok := afterTerms[term]; !ok {
+					remove = append(remove, types.SearchPosting{Term: term, Id: id})
+				}
+			}
+		}
+
+		for term, weight := range afterTerms {
+			put = append(put, types.SearchPosting{Term: term, Id: id, Weight: weight})
+		}
+	}
+
+	if len(put) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	if err := p.index.Write(ctx, put, remove); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// Rebuild clears the index and indexes every product of the store, one page
+// at a time, and returns how many it indexed. Changes projected while it
+// runs may be lost, so the stream should be drained or paused meanwhile.
+func (p *SearchProjector) Rebuild(ctx context.Context, s types.Store) (int, error) {
+	if err := p.index.Clear(ctx); err != nil {
+		return 0, fmt.Errorf("%w", err)
+	}
+
+	indexed := 0
+	var next *string
+
+	for {
+		productRange, err := s.All(ctx, next)
+		if err != nil {
+			return indexed, fmt.Errorf("%w", err)
+		}
+
+		changes := make([]types.ProductChange, len(productRange.Products))
+		for i := range productRange.Products {
+			changes[i] = types.ProductChange{Type: types.ProductCreated, After: &productRange.Products[i]}
+		}
+
+		if err := p.Project(ctx, changes); err != nil {
+			return indexed, err
+		}
+		indexed += len(changes)
+
+		if productRange.Next == nil {
+			return indexed, nil
+		}
+		next = productRange.Next
+	}
+}
+
+func productId(change types.ProductChange) string {
+	if change.After != nil {
+		return change.After.Id
+	}
+
+	if change.Before != nil {
+		return change.Before.Id
+	}
+
+	return ""
+}
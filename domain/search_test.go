@@ -0,0 +1,172 @@
+//go:build unit
+// +build unit
+
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws-samples/serverless-go-demo/store"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+func searchIds(t *testing.T, search *Search, query string, next *string) ([]string, *string) {
+	t.Helper()
+
+	productRange, err := search.Search(context.Background(), query, next)
+	if err != nil {
+		t.Fatalf("Search returned an error: %s", err)
+	}
+
+	ids := []string{}
+	for _, product := range productRange.Products {
+		ids = append(ids, product.Id)
+	}
+
+	return ids, productRange.Next
+}
+
+func TestSearchRanksAndPaginates(t *testing.T) {
+	ctx := context.Background()
+	productStore := store.NewMemoryStore()
+	index := store.NewMemorySearchIndex()
+
+	products := []types.Product{
+		{Id: "shoes", Name: "Red Shoes", Price: 40},
+		{Id: "case", Name: "Red Phone Case", Price: 12, Tags: []string{"accessory"}},
+		{Id: "phone", Name: "Blue Phone", Price: 700},
+		{Id: "tagged", Name: "Charger", Price: 20, Tags: []string{"phone"}},
+	}
+	changes := []types.ProductChange{}
+	for i := range products {
+		productStore.Put(ctx, products[i])
+		changes = append(changes, types.ProductChange{Type: types.ProductCreated, After: &products[i]})
+	}
+
+	if err := NewSearchProjector(index).Project(ctx, changes); err != nil {
+		t.Fatalf("Project returned an error: %s", err)
+	}
+
+	search := NewSearch(index, productStore, WithSearchPageSize(2))
+
+	ids, next := searchIds(t, search, "red PHONE!", nil)
+	if !reflect.DeepEqual(ids, []string{"case", "phone"}) || next == nil {
+		t.Fatalf("Got %v and next %v, expected [case phone] and a next page", ids, next)
+	}
+
+	ids, next = searchIds(t, search, "red PHONE!", next)
+	if !reflect.DeepEqual(ids, []string{"shoes", "tagged"}) || next != nil {
+		t.Errorf("Got %v and next %v, expected [shoes tagged] and no next page", ids, next)
+	}
+
+	ids, _ = searchIds(t, search, "price:10-50", nil)
+	if !reflect.DeepEqual(ids, []string{"case", "shoes"}) {
+		t.Errorf("Got %v in price:10-50, expected [case shoes] on the first page", ids)
+	}
+
+	if _, err := search.Search(ctx, " ?! ", nil); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("Got error %v for a query without terms", err)
+	}
+
+	if _, err := search.Search(ctx, strings.Repeat("red phone ", MaxSearchTerms), nil); err != nil {
+		t.Errorf("Got error %v for a query repeating its terms", err)
+	}
+
+	words := []string{}
+	for i := 0; i <= MaxSearchTerms; i++ {
+		words = append(words, fmt.Sprint("word", i))
+	}
+	if _, err := search.Search(ctx, strings.Join(words, " "), nil); !errors.Is(err, ErrTooManyTerms) {
+		t.Errorf("Got error %v for a query of %d terms", err, len(words))
+	}
+
+	invalid := "-1"
+	if _, err := search.Search(ctx, "red", &invalid); !errors.Is(err, ErrInvalidSearchNext) {
+		t.Errorf("Got error %v for a negative offset", err)
+	}
+}
+
+func TestProjectFoldsChangesOfAProduct(t *testing.T) {
+	ctx := context.Background()
+	productStore := store.NewMemoryStore()
+	index := store.NewMemorySearchIndex()
+	projector := NewSearchProjector(index)
+	search := NewSearch(index, productStore)
+
+	created := types.Product{Id: "iXR", Name: "iPhone XML", Price: 1}
+	renamed := types.Product{Id: "iXR", Name: "iPhone YAML", Price: 1, Tags: []string{"config"}}
+	repriced := types.Product{Id: "iXR", Name: "iPhone YAML", Price: 60, Tags: []string{"config"}}
+	productStore.Put(ctx, repriced)
+
+	projector.Project(ctx, []types.ProductChange{{Type: types.ProductCreated, After: &created}})
+	err := projector.Project(ctx, []types.ProductChange{
+		{Type: types.ProductUpdated, Before: &created, After: &renamed},
+		{Type: types.ProductUpdated, Before: &renamed, After: &repriced},
+	})
+	if err != nil {
+		t.Fatalf("Project returned an error: %s", err)
+	}
+
+	for query, expected := range map[string][]string{
+		"xml":          {},
+		"price:0-10":   {},
+		"yaml":         {"iXR"},
+		"config":       {"iXR"},
+		"price:50-100": {"iXR"},
+	} {
+		if ids, _ := searchIds(t, search, query, nil); !reflect.DeepEqual(ids, expected) {
+			t.Errorf("Got %v for '%s', expected %v", ids, query, expected)
+		}
+	}
+
+	tombstone := repriced
+	tombstone.Deleted = true
+	projector.Project(ctx, []types.ProductChange{
+		{Type: types.ProductUpdated, Before: &repriced, After: &tombstone},
+		{Type: types.ProductDeleted, Before: &tombstone},
+	})
+
+	if postings, _ := index.Postings(ctx, []string{"iphone", "yaml", "config", "price:50-100"}); len(postings) != 0 {
+		t.Errorf("Got postings %+v after the deletion", postings)
+	}
+}
+
+func TestRebuildIndexesEveryProduct(t *testing.T) {
+	ctx := context.Background()
+	productStore := store.NewMemoryStore()
+	index := store.NewMemorySearchIndex()
+
+	productStore.Put(ctx, types.Product{Id: "iXR", Name: "iPhone XML"})
+	productStore.Put(ctx, types.Product{Id: "iXS", Name: "iPhone YAML"})
+	index.Write(ctx, []types.SearchPosting{{Term: "stale", Id: "gone", Weight: 1}}, nil)
+
+	indexed, err := NewSearchProjector(index).Rebuild(ctx, productStore)
+	if err != nil || indexed != 2 {
+		t.Fatalf("Got %d products indexed and error %v, expected 2", indexed, err)
+	}
+
+	ids, _ := searchIds(t, NewSearch(index, productStore), "iphone stale", nil)
+	if !reflect.DeepEqual(ids, []string{"iXR", "iXS"}) {
+		t.Errorf("Got %v, expected the products of the store only", ids)
+	}
+}
+
+func TestPriceBucket(t *testing.T) {
+	for price, expected := range map[float64]string{
+		0:      "price:0-10",
+		9.99:   "price:0-10",
+		10:     "price:10-50",
+		499.5:  "price:100-500",
+		1000:   "price:1000+",
+		250000: "price:1000+",
+	} {
+		if bucket := priceBucket(price); bucket != expected {
+			t.Errorf("Got bucket %s for %g, expected %s", bucket, price, expected)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	dataKeySize = 32
+	// envelopePrefix marks encrypted values, so that values written before an
+	// attribute was encrypted can still be read.
+	envelopePrefix = "enc:v1:"
+	// maxCachedDataKeys bounds the data keys kept decrypted in memory.
+	maxCachedDataKeys = 256
+)
+
+var ErrDecrypt = errors.New("unable to decrypt value")
+
+// envelope holds everything needed to decrypt a value except the master key:
+// the id of that key, the data key it encrypted, and the value encrypted with
+// AES-256-GCM by the data key, prefixed by its nonce.
+type envelope struct {
+	KeyId        string `json:"kid"`
+	EncryptedKey []byte `json:"key"`
+	Ciphertext   []byte `json:"ct"`
+}
+
+// Encrypter seals values in envelopes with data keys from a KeyProvider, and
+// opens them again. Decrypted data keys are cached so that reading many values
+// sealed with the same data key costs a single call to the provider.
+type Encrypter struct {
+	provider KeyProvider
+
+	mu    sync.Mutex
+	cache map[string][]byte
+}
+
+func NewEncrypter(p KeyProvider) *Encrypter {
+	return &Encrypter{
+		provider: p,
+		cache:    map[string][]byte{},
+	}
+}
+
+// NewDataKey returns a data key for sealing the values written together.
+func (e *Encrypter) NewDataKey(ctx context.Context) (DataKey, error) {
+	return e.provider.GenerateDataKey(ctx)
+}
+
+// Seal encrypts plaintext with the data key. The additional data isn't stored
+// but must be given again to Open, binding the value to its context, such as
+// the item and attribute it belongs to.
+func (e *Encrypter) Seal(key DataKey, plaintext []byte, additionalData []byte) (string, error) {
+	ciphertext, err := seal(key.Plaintext, plaintext, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("unable to encrypt value: %w", err)
+	}
+
+	data, err := json.Marshal(envelope{
+		KeyId:        key.KeyId,
+		EncryptedKey: key.Encrypted,
+		Ciphertext:   ciphertext,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to encrypt value: %w", err)
+	}
+
+	return envelopePrefix + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Open decrypts a value sealed by Seal with the same additional data.
+func (e *Encrypter) Open(ctx context.Context, sealed string, additionalData []byte) ([]byte, error) {
+	env, err := parseEnvelope(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := e.dataKey(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecrypt, err)
+	}
+
+	plaintext, err := open(key, env.Ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecrypt, err)
+	}
+
+	return plaintext, nil
+}
+
+// KeyId returns the id of the master key that protects a sealed value, which
+// tells which values still need to be rewritten after a rotation.
+func KeyId(sealed string) (string, error) {
+	env, err := parseEnvelope(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	return env.KeyId, nil
+}
+
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+func parseEnvelope(sealed string) (envelope, error) {
+	if !IsSealed(sealed) {
+		return envelope{}, fmt.Errorf("%w: not an encrypted value", ErrDecrypt)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(sealed, envelopePrefix))
+	if err != nil {
+		return envelope{}, fmt.Errorf("%w: %s", ErrDecrypt, err)
+	}
+
+	env := envelope{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return envelope{}, fmt.Errorf("%w: %s", ErrDecrypt, err)
+	}
+
+	return env, nil
+}
+
+func (e *Encrypter) dataKey(ctx context.Context, env envelope) ([]byte, error) {
+	cacheKey := env.KeyId + "/" + string(env.EncryptedKey)
+
+	e.mu.Lock()
+	key, ok := e.cache[cacheKey]
+	e.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	key, err := e.provider.DecryptDataKey(ctx, env.KeyId, env.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	if len(e.cache) >= maxCachedDataKeys {
+		e.cache = map[string][]byte{}
+	}
+	e.cache[cacheKey] = key
+	e.mu.Unlock()
+
+	return key, nil
+}
+
+func seal(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,78 @@
+//go:build unit
+// +build unit
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func testKeys(t *testing.T, current string, ids ...string) *FileKeyProvider {
+	keys := KeyFile{CurrentKeyId: current, Keys: map[string][]byte{}}
+	for _, id := range ids {
+		keys.Keys[id] = bytes.Repeat([]byte(id[len(id)-1:]), dataKeySize)
+	}
+
+	provider, err := NewFileKeyProvider(keys)
+	if err != nil {
+		t.Fatalf("NewFileKeyProvider returned an error: %s", err)
+	}
+
+	return provider
+}
+
+func TestSealAndOpen(t *testing.T) {
+	ctx := context.Background()
+	encrypter := NewEncrypter(testKeys(t, "k1", "k1"))
+
+	key, err := encrypter.NewDataKey(ctx)
+	if err != nil {
+		t.Fatalf("NewDataKey returned an error: %s", err)
+	}
+
+	sealed, err := encrypter.Seal(key, []byte("12.50"), []byte("product-1"))
+	if err != nil {
+		t.Fatalf("Seal returned an error: %s", err)
+	}
+
+	if !IsSealed(sealed) || bytes.Contains([]byte(sealed), []byte("12.50")) {
+		t.Fatalf("Got unexpected sealed value %s", sealed)
+	}
+
+	plaintext, err := encrypter.Open(ctx, sealed, []byte("product-1"))
+	if err != nil || string(plaintext) != "12.50" {
+		t.Errorf("Open returned %q, %v", plaintext, err)
+	}
+
+	if _, err := encrypter.Open(ctx, sealed, []byte("product-2")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Got error %v when opening with other additional data, expected ErrDecrypt", err)
+	}
+}
+
+func TestOpenAfterRotation(t *testing.T) {
+	ctx := context.Background()
+
+	before := NewEncrypter(testKeys(t, "k1", "k1"))
+	key, _ := before.NewDataKey(ctx)
+	sealed, _ := before.Seal(key, []byte("contract-42"), nil)
+
+	after := NewEncrypter(testKeys(t, "k2", "k1", "k2"))
+	plaintext, err := after.Open(ctx, sealed, nil)
+	if err != nil || string(plaintext) != "contract-42" {
+		t.Errorf("Open returned %q, %v for a value sealed before the rotation", plaintext, err)
+	}
+
+	key, _ = after.NewDataKey(ctx)
+	sealed, _ = after.Seal(key, []byte("contract-42"), nil)
+	if keyId, _ := KeyId(sealed); keyId != "k2" {
+		t.Errorf("Got key id %s for a value sealed after the rotation, expected k2", keyId)
+	}
+
+	retired := NewEncrypter(testKeys(t, "k2", "k2"))
+	if _, err := retired.Open(ctx, sealed, nil); err != nil {
+		t.Errorf("Open returned an error once k1 is retired: %s", err)
+	}
+}
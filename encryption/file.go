@@ -0,0 +1,88 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// KeyFile is the JSON content of a key file: base64 encoded 256-bit master
+// keys by id, and the id of the one used for new data keys.
+type KeyFile struct {
+	CurrentKeyId string            `json:"currentKeyId"`
+	Keys         map[string][]byte `json:"keys"`
+}
+
+// FileKeyProvider wraps data keys with master keys kept in a local file. It is
+// meant for tests and local runs, where KMS isn't available.
+type FileKeyProvider struct {
+	currentKeyId string
+	keys         map[string][]byte
+}
+
+var _ KeyProvider = (*FileKeyProvider)(nil)
+
+func NewFileKeyProvider(keys KeyFile) (*FileKeyProvider, error) {
+	if _, ok := keys.Keys[keys.CurrentKeyId]; !ok {
+		return nil, fmt.Errorf("current key '%s' is not in the key file", keys.CurrentKeyId)
+	}
+
+	for id, key := range keys.Keys {
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("key '%s' must be %d bytes long, got %d", id, dataKeySize, len(key))
+		}
+	}
+
+	return &FileKeyProvider{
+		currentKeyId: keys.CurrentKeyId,
+		keys:         keys.Keys,
+	}, nil
+}
+
+func LoadKeyFile(path string) (*FileKeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key file: %w", err)
+	}
+
+	keys := KeyFile{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("unable to parse key file: %w", err)
+	}
+
+	return NewFileKeyProvider(keys)
+}
+
+func (f *FileKeyProvider) GenerateDataKey(ctx context.Context) (DataKey, error) {
+	plaintext := make([]byte, dataKeySize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return DataKey{}, fmt.Errorf("unable to generate data key: %w", err)
+	}
+
+	encrypted, err := seal(f.keys[f.currentKeyId], plaintext, []byte(f.currentKeyId))
+	if err != nil {
+		return DataKey{}, fmt.Errorf("unable to encrypt data key: %w", err)
+	}
+
+	return DataKey{
+		KeyId:     f.currentKeyId,
+		Plaintext: plaintext,
+		Encrypted: encrypted,
+	}, nil
+}
+
+func (f *FileKeyProvider) DecryptDataKey(ctx context.Context, keyId string, encrypted []byte) ([]byte, error) {
+	key, ok := f.keys[keyId]
+	if !ok {
+		return nil, fmt.Errorf("unknown key '%s'", keyId)
+	}
+
+	plaintext, err := open(key, encrypted, []byte(keyId))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt data key: %w", err)
+	}
+
+	return plaintext, nil
+}
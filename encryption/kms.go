@@ -0,0 +1,63 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// KMSKeyProvider generates and decrypts data keys with AWS KMS. Data keys are
+// generated with the configured key, which can be an id, ARN or alias, and are
+// decrypted with the key that generated them. Pointing the alias to a new key
+// therefore rotates it without breaking existing values.
+type KMSKeyProvider struct {
+	client *kms.Client
+	keyId  string
+}
+
+var _ KeyProvider = (*KMSKeyProvider)(nil)
+
+func NewKMSKeyProvider(ctx context.Context, keyId string) *KMSKeyProvider {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	return &KMSKeyProvider{
+		client: kms.NewFromConfig(cfg),
+		keyId:  keyId,
+	}
+}
+
+func (k *KMSKeyProvider) GenerateDataKey(ctx context.Context) (DataKey, error) {
+	output, err := k.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   &k.keyId,
+		KeySpec: kmstypes.DataKeySpecAes256,
+	})
+	if err != nil {
+		return DataKey{}, fmt.Errorf("unable to generate data key: %w", err)
+	}
+
+	return DataKey{
+		KeyId:     aws.ToString(output.KeyId),
+		Plaintext: output.Plaintext,
+		Encrypted: output.CiphertextBlob,
+	}, nil
+}
+
+func (k *KMSKeyProvider) DecryptDataKey(ctx context.Context, keyId string, encrypted []byte) ([]byte, error) {
+	output, err := k.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:          &keyId,
+		CiphertextBlob: encrypted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt data key: %w", err)
+	}
+
+	return output.Plaintext, nil
+}
@@ -0,0 +1,42 @@
+package encryption
+
+import (
+	"context"
+	"os"
+)
+
+// DataKey is a key used to encrypt a few values, given both in plaintext and
+// encrypted by the master key KeyId, so that only the encrypted copy is stored.
+type DataKey struct {
+	KeyId     string
+	Plaintext []byte
+	Encrypted []byte
+}
+
+// KeyProvider generates data keys with its current master key and decrypts
+// data keys generated by any of its master keys, current or previous, which is
+// what allows master keys to be rotated.
+type KeyProvider interface {
+	GenerateDataKey(context.Context) (DataKey, error)
+	DecryptDataKey(ctx context.Context, keyId string, encrypted []byte) ([]byte, error)
+}
+
+// NewKeyProviderFromEnv returns a KMS key provider for the key named by
+// KMS_KEY_ID, or a file key provider for the key file named by
+// ENCRYPTION_KEY_FILE. It returns nil when neither is set.
+func NewKeyProviderFromEnv(ctx context.Context) (KeyProvider, error) {
+	if keyId, ok := os.LookupEnv("KMS_KEY_ID"); ok && keyId != "" {
+		return NewKMSKeyProvider(ctx, keyId), nil
+	}
+
+	if keyFile, ok := os.LookupEnv("ENCRYPTION_KEY_FILE"); ok && keyFile != "" {
+		provider, err := LoadKeyFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return provider, nil
+	}
+
+	return nil, nil
+}
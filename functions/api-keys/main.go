@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/handlers"
+	"github.com/aws-samples/serverless-go-demo/store"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	tableName, ok := os.LookupEnv("API_KEYS_TABLE")
+	if !ok {
+		panic("Need API_KEYS_TABLE environment variable")
+	}
+
+	dynamodb := store.NewDynamoDBAPIKeyStore(context.TODO(), tableName)
+	domain := domain.NewAPIKeysDomain(dynamodb)
+	handler := handlers.NewAPIKeysHandler(domain)
+	routes := handler.Routes()
+	router := handlers.NewRouter(routes...)
+
+	fn := handlers.Validate(routes)(router.Handler)
+	lambda.Start(fn)
+}
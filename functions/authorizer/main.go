@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/handlers"
+	"github.com/aws-samples/serverless-go-demo/store"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	tableName, ok := os.LookupEnv("API_KEYS_TABLE")
+	if !ok {
+		panic("Need API_KEYS_TABLE environment variable")
+	}
+
+	dynamodb := store.NewDynamoDBAPIKeyStore(context.TODO(), tableName)
+	domain := domain.NewAPIKeysDomain(dynamodb)
+	handler := handlers.NewAPIKeyAuthorizerHandler(domain)
+	lambda.Start(handler.Handler)
+}
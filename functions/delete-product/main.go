@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"log"
 	"os"
 
 	"github.com/aws-samples/serverless-go-demo/domain"
@@ -17,8 +18,46 @@ func main() {
 		panic("Need TABLE environment variable")
 	}
 
-	dynamodb := store.NewDynamoDBStore(context.TODO(), tableName)
-	domain := domain.NewProductsDomain(dynamodb)
-	handler := handlers.NewAPIGatewayV2Handler(domain)
-	lambda.Start(handler.DeleteHandler)
+	storeOpts := []store.DynamoDBStoreOption{}
+	if outboxTable := os.Getenv("OUTBOX_TABLE"); outboxTable != "" {
+		storeOpts = append(storeOpts, store.WithOutboxTable(outboxTable))
+	}
+
+	productStore, err := store.NewProductStoreFromEnv(context.TODO(), tableName, storeOpts...)
+	if err != nil {
+		log.Fatalf("unable to configure the product store, %v", err)
+	}
+	products := domain.NewProductsDomain(productStore)
+	handler := handlers.NewAPIGatewayV2Handler(products)
+
+	fn := handlers.Validate(handler.Routes())(handler.DeleteHandler)
+	if idempotencyTable, ok := os.LookupEnv("IDEMPOTENCY_TABLE"); ok {
+		idempotencyStore := store.NewDynamoDBIdempotencyStore(context.TODO(), idempotencyTable)
+		fn = handlers.Idempotent(idempotencyStore, handlers.DefaultIdempotencyTTL)(fn)
+	}
+	if rateLimits := os.Getenv("RATE_LIMITS"); rateLimits != "" {
+		config, err := handlers.ParseRateLimitConfig(rateLimits)
+		if err != nil {
+			log.Fatalf("unable to load rate limits, %v", err)
+		}
+		rateLimitTable := os.Getenv("RATE_LIMIT_TABLE")
+		if rateLimitTable == "" {
+			log.Fatal("Need RATE_LIMIT_TABLE environment variable to enforce RATE_LIMITS")
+		}
+		limiter := domain.NewRateLimiter(store.NewDynamoDBRateLimitStore(context.TODO(), rateLimitTable))
+		fn = handlers.RateLimit(limiter, config, handler.Routes())(fn)
+	}
+	authorize, err := handlers.AuthorizeFromEnv(handler.Routes(), store.NewAPIKeyStoreFromEnv(context.TODO()))
+	if err != nil {
+		log.Fatalf("unable to configure authorization, %v", err)
+	}
+	fn = authorize(fn)
+	fn = handlers.Compress(handlers.DefaultCompressionThreshold)(fn)
+	corsConfig, err := handlers.CORSConfigFromEnv()
+	if err != nil {
+		log.Fatalf("unable to load CORS configuration, %v", err)
+	}
+	fn = handlers.CORS(corsConfig)(fn)
+
+	lambda.Start(fn)
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/aws-samples/serverless-go-demo/bus"
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/handlers"
+	"github.com/aws-samples/serverless-go-demo/store"
+)
+
+func main() {
+	outboxTable, ok := os.LookupEnv("OUTBOX_TABLE")
+	if !ok || outboxTable == "" {
+		panic("Need OUTBOX_TABLE environment variable")
+	}
+
+	eventBus, err := bus.NewBusFromEnv(context.TODO())
+	if err != nil {
+		log.Fatalf("unable to configure the event bus, %v", err)
+	}
+
+	outbox := store.NewDynamoDBOutbox(context.TODO(), outboxTable)
+	opts := []domain.OutboxRelayOption{}
+	if searchTable := os.Getenv("SEARCH_TABLE"); searchTable != "" {
+		opts = append(opts, domain.WithSearchProjector(domain.NewSearchProjector(store.NewDynamoDBSearchIndex(context.TODO(), searchTable))))
+	}
+	if deadLetterTable := os.Getenv("DEAD_LETTER_TABLE"); deadLetterTable != "" {
+		maxAttempts := domain.DefaultMaxOutboxAttempts
+		if attempts, ok := os.LookupEnv("DEAD_LETTER_MAX_ATTEMPTS"); ok {
+			if maxAttempts, err = strconv.Atoi(attempts); err != nil || maxAttempts < 1 {
+				log.Fatalf("invalid DEAD_LETTER_MAX_ATTEMPTS '%s'", attempts)
+			}
+		}
+		opts = append(opts, domain.WithOutboxDeadLetters(store.NewDynamoDBDeadLetterStore(context.TODO(), deadLetterTable), maxAttempts))
+	}
+	relay := domain.NewOutboxRelay(outbox, domain.NewProductsStream(eventBus), opts...)
+
+	handler := handlers.NewOutboxRelayHandler(relay)
+	lambda.Start(handler.ScheduledHandler)
+}
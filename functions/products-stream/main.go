@@ -2,23 +2,53 @@ package main
 
 import (
 	"context"
+	"log"
 	"os"
+	"strconv"
 
 	"github.com/aws/aws-lambda-go/lambda"
 
 	"github.com/aws-samples/serverless-go-demo/bus"
 	"github.com/aws-samples/serverless-go-demo/domain"
 	"github.com/aws-samples/serverless-go-demo/handlers"
+	"github.com/aws-samples/serverless-go-demo/store"
 )
 
 func main() {
-	eventBusName, ok := os.LookupEnv("EVENT_BUS_NAME")
-	if !ok {
-		panic("Need EVENT_BUS_NAME environment variable")
+	eventBus, err := bus.NewBusFromEnv(context.TODO())
+	if err != nil {
+		log.Fatalf("unable to configure the event bus, %v", err)
 	}
 
-	store := bus.NewEventBridgeBus(context.TODO(), eventBusName)
-	domain := domain.NewProductsStream(store)
-	handler := handlers.NewDynamoDBEventHandler(domain)
+	productsStream := domain.NewProductsStream(eventBus)
+
+	opts := []handlers.DynamoDBEventHandlerOption{}
+	maxAttempts := handlers.DefaultMaxRecordAttempts
+	if attempts, ok := os.LookupEnv("DEAD_LETTER_MAX_ATTEMPTS"); ok {
+		if maxAttempts, err = strconv.Atoi(attempts); err != nil || maxAttempts < 1 {
+			log.Fatalf("invalid DEAD_LETTER_MAX_ATTEMPTS '%s'", attempts)
+		}
+	}
+	switch sink := os.Getenv("DEAD_LETTER_SINK"); sink {
+	case "", "none":
+	case "log":
+		opts = append(opts, handlers.WithDeadLetterSink(store.NewLogDeadLetterSink(nil), maxAttempts))
+	case "dynamodb":
+		tableName, ok := os.LookupEnv("DEAD_LETTER_TABLE")
+		if !ok || tableName == "" {
+			log.Fatal("Need DEAD_LETTER_TABLE environment variable for the dynamodb dead-letter sink")
+		}
+		opts = append(opts, handlers.WithDeadLetterSink(store.NewDynamoDBDeadLetterStore(context.TODO(), tableName), maxAttempts))
+	case "file":
+		path, ok := os.LookupEnv("DEAD_LETTER_FILE")
+		if !ok || path == "" {
+			log.Fatal("Need DEAD_LETTER_FILE environment variable for the file dead-letter sink")
+		}
+		opts = append(opts, handlers.WithDeadLetterSink(store.NewFileDeadLetterStore(path), maxAttempts))
+	default:
+		log.Fatalf("unknown DEAD_LETTER_SINK '%s'", sink)
+	}
+
+	handler := handlers.NewDynamoDBEventHandler(productsStream, opts...)
 	lambda.Start(handler.StreamHandler)
 }
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/handlers"
+	"github.com/aws-samples/serverless-go-demo/store"
+)
+
+func main() {
+	searchTable, ok := os.LookupEnv("SEARCH_TABLE")
+	if !ok || searchTable == "" {
+		panic("Need SEARCH_TABLE environment variable")
+	}
+
+	projector := domain.NewSearchProjector(store.NewDynamoDBSearchIndex(context.TODO(), searchTable))
+
+	handler := handlers.NewSearchProjectorHandler(projector)
+	lambda.Start(handler.StreamHandler)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/handlers"
+	"github.com/aws-samples/serverless-go-demo/store"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	tableName, ok := os.LookupEnv("TABLE")
+	if !ok {
+		panic("Need TABLE environment variable")
+	}
+
+	searchTable, ok := os.LookupEnv("SEARCH_TABLE")
+	if !ok || searchTable == "" {
+		panic("Need SEARCH_TABLE environment variable")
+	}
+
+	productStore, err := store.NewProductStoreFromEnv(context.TODO(), tableName)
+	if err != nil {
+		log.Fatalf("unable to configure the product store, %v", err)
+	}
+	search := domain.NewSearch(store.NewDynamoDBSearchIndex(context.TODO(), searchTable), productStore)
+	handler := handlers.NewSearchHandler(search)
+
+	fn := handlers.Validate(handler.Routes())(handler.SearchHandler)
+	if rateLimits := os.Getenv("RATE_LIMITS"); rateLimits != "" {
+		config, err := handlers.ParseRateLimitConfig(rateLimits)
+		if err != nil {
+			log.Fatalf("unable to load rate limits, %v", err)
+		}
+		rateLimitTable := os.Getenv("RATE_LIMIT_TABLE")
+		if rateLimitTable == "" {
+			log.Fatal("Need RATE_LIMIT_TABLE environment variable to enforce RATE_LIMITS")
+		}
+		limiter := domain.NewRateLimiter(store.NewDynamoDBRateLimitStore(context.TODO(), rateLimitTable))
+		fn = handlers.RateLimit(limiter, config, handler.Routes())(fn)
+	}
+	authorize, err := handlers.AuthorizeFromEnv(handler.Routes(), store.NewAPIKeyStoreFromEnv(context.TODO()))
+	if err != nil {
+		log.Fatalf("unable to configure authorization, %v", err)
+	}
+	fn = authorize(fn)
+	fn = handlers.Compress(handlers.DefaultCompressionThreshold)(fn)
+	corsConfig, err := handlers.CORSConfigFromEnv()
+	if err != nil {
+		log.Fatalf("unable to load CORS configuration, %v", err)
+	}
+	fn = handlers.CORS(corsConfig)(fn)
+
+	lambda.Start(fn)
+}
@@ -3,16 +3,22 @@ module github.com/aws-samples/serverless-go-demo
 go 1.17
 
 require (
+	github.com/andybalholm/brotli v1.0.4
 	github.com/aws/aws-lambda-go v1.27.0
 	github.com/aws/aws-sdk-go-v2 v1.11.2
 	github.com/aws/aws-sdk-go-v2/config v1.11.0
 	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.4.4
 	github.com/aws/aws-sdk-go-v2/service/cloudwatchevents v1.9.2
 	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.10.0
+	github.com/aws/aws-sdk-go-v2/service/kinesis v1.11.0
+	github.com/aws/aws-sdk-go-v2/service/kms v1.11.1
+	github.com/aws/aws-sdk-go-v2/service/sns v1.12.1
+	github.com/aws/aws-sdk-go-v2/service/sqs v1.13.1
 	github.com/golang/mock v1.6.0
 )
 
 require (
+	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.0.0 // indirect
 	github.com/aws/aws-sdk-go-v2/credentials v1.6.4 // indirect
 	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.8.2 // indirect
 	github.com/aws/aws-sdk-go-v2/internal/configsources v1.1.2 // indirect
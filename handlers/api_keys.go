@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultRotationGracePeriod is how long the previous secret keeps working
+// after a rotation, unless the request asks for another period.
+const DefaultRotationGracePeriod = 24 * time.Hour
+
+type IssueAPIKeyRequest struct {
+	Owner     string     `json:"owner"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type RotateAPIKeyRequest struct {
+	GracePeriodSeconds *int `json:"gracePeriodSeconds,omitempty"`
+}
+
+// APIKeysHandler serves the admin endpoints used to manage API keys. They are
+// meant to be protected by IAM authorization on the HTTP API.
+type APIKeysHandler struct {
+	apiKeys *domain.APIKeys
+}
+
+func NewAPIKeysHandler(d *domain.APIKeys) *APIKeysHandler {
+	return &APIKeysHandler{
+		apiKeys: d,
+	}
+}
+
+func (h *APIKeysHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:      http.MethodPost,
+			Path:        "/keys",
+			Handler:     h.IssueHandler,
+			Name:        "issueAPIKey",
+			Summary:     "Issue an API key",
+			RequestBody: IssueAPIKeyRequest{},
+			Response:    domain.IssuedAPIKey{},
+			Status:      http.StatusCreated,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/keys/{id}/rotate",
+			Handler:  h.RotateHandler,
+			Name:     "rotateAPIKey",
+			Summary:  "Replace the secret of an API key",
+			Response: domain.IssuedAPIKey{},
+			Status:   http.StatusOK,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/keys/{id}",
+			Handler: h.RevokeHandler,
+			Name:    "revokeAPIKey",
+			Summary: "Revoke an API key",
+			Status:  http.StatusOK,
+		},
+	}
+}
+
+func (h *APIKeysHandler) IssueHandler(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	body, err := requestBody(event)
+	if err != nil {
+		return errResponse(http.StatusBadRequest, err.Error()), nil
+	}
+
+	request := IssueAPIKeyRequest{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return errResponse(http.StatusBadRequest, "failed to parse API key request from request body"), nil
+	}
+
+	issued, err := h.apiKeys.Issue(ctx, request.Owner, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		return apiKeyErrResponse(err), nil
+	}
+
+	return response(http.StatusCreated, issued), nil
+}
+
+func (h *APIKeysHandler) RotateHandler(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	id, ok := event.PathParameters["id"]
+	if !ok {
+		return errResponse(http.StatusBadRequest, "missing 'id' parameter in path"), nil
+	}
+
+	grace := DefaultRotationGracePeriod
+
+	body, err := requestBody(event)
+	if err != nil {
+		return errResponse(http.StatusBadRequest, err.Error()), nil
+	}
+
+	if len(body) > 0 {
+		request := RotateAPIKeyRequest{}
+		if err := json.Unmarshal(body, &request); err != nil {
+			return errResponse(http.StatusBadRequest, "failed to parse rotation request from request body"), nil
+		}
+
+		if request.GracePeriodSeconds != nil {
+			grace = time.Duration(*request.GracePeriodSeconds) * time.Second
+		}
+	}
+
+	issued, err := h.apiKeys.Rotate(ctx, id, grace)
+	if err != nil {
+		return apiKeyErrResponse(err), nil
+	}
+
+	return response(http.StatusOK, issued), nil
+}
+
+func (h *APIKeysHandler) RevokeHandler(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	id, ok := event.PathParameters["id"]
+	if !ok {
+		return errResponse(http.StatusBadRequest, "missing 'id' parameter in path"), nil
+	}
+
+	if err := h.apiKeys.Revoke(ctx, id); err != nil {
+		return apiKeyErrResponse(err), nil
+	}
+
+	return response(http.StatusOK, nil), nil
+}
+
+func apiKeyErrResponse(err error) events.APIGatewayV2HTTPResponse {
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return errResponse(http.StatusNotFound, err.Error())
+	} else if errors.Is(err, domain.ErrAPIKeyRequest) {
+		return errResponse(http.StatusBadRequest, err.Error())
+	} else if errors.Is(err, domain.ErrAPIKeyRevoked) || errors.Is(err, domain.ErrAPIKeyExpired) {
+		return errResponse(http.StatusConflict, err.Error())
+	} else {
+		return errResponse(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -57,14 +59,21 @@ func (l *APIGatewayV2Handler) PutHandler(ctx context.Context, event events.APIGa
 		return errResponse(http.StatusBadRequest, "missing 'id' parameter in path"), nil
 	}
 
-	if strings.TrimSpace(event.Body) == "" {
+	body, err := requestBody(event)
+	if err != nil {
+		return errResponse(http.StatusBadRequest, err.Error()), nil
+	}
+
+	if strings.TrimSpace(string(body)) == "" {
 		return errResponse(http.StatusBadRequest, "empty request body"), nil
 	}
 
-	product, err := l.products.PutProduct(ctx, id, []byte(event.Body))
+	product, err := l.products.PutProduct(ctx, id, body)
 	if err != nil {
 		if errors.Is(err, domain.ErrJsonUnmarshal) || errors.Is(err, domain.ErrProductIdMismatch) {
 			return errResponse(http.StatusBadRequest, err.Error()), nil
+		} else if errors.Is(err, domain.ErrBodyTooLarge) {
+			return errResponse(http.StatusRequestEntityTooLarge, err.Error()), nil
 		} else {
 			return errResponse(http.StatusInternalServerError, err.Error()), nil
 		}
@@ -81,12 +90,29 @@ func (l *APIGatewayV2Handler) DeleteHandler(ctx context.Context, event events.AP
 
 	err := l.products.DeleteProduct(ctx, id)
 	if err != nil {
-		return errResponse(http.StatusInternalServerError, err.Error()), nil
+		if errors.Is(err, domain.ErrDeleteConflict) {
+			return errResponse(http.StatusConflict, err.Error()), nil
+		} else {
+			return errResponse(http.StatusInternalServerError, err.Error()), nil
+		}
 	}
 
 	return response(http.StatusOK, nil), nil
 }
 
+func requestBody(event events.APIGatewayV2HTTPRequest) ([]byte, error) {
+	if !event.IsBase64Encoded {
+		return []byte(event.Body), nil
+	}
+
+	body, err := base64.StdEncoding.DecodeString(event.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 request body: %w", err)
+	}
+
+	return body, nil
+}
+
 func response(code int, object interface{}) events.APIGatewayV2HTTPResponse {
 	marshalled, err := json.Marshal(object)
 	if err != nil {
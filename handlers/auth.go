@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws-samples/serverless-go-demo/auth"
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+)
+
+// Authorize requires every request to a route with scopes to come from a
+// principal holding all of them. The principal is read from the JWT or API key
+// authorizer of API Gateway when there is one, or from a bearer token verified
+// locally with the verifier, which can be nil. It is then stored in the context
+// with auth.NewContext. Failures are answered with 401 or 403 problem responses.
+func Authorize(routes []Route, verifier *auth.JWTVerifier) Middleware {
+	router := NewRouter(routes...)
+
+	return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+		return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			route, _, _ := router.match(event)
+			if route == nil || len(route.Scopes) == 0 {
+				return next(ctx, event)
+			}
+
+			principal, err := principalFromRequest(event, verifier)
+			if err != nil {
+				resp := problemResponse(http.StatusUnauthorized, err.Error())
+				resp.Headers["WWW-Authenticate"] = `Bearer error="invalid_token"`
+				return resp, nil
+			}
+
+			for _, scope := range route.Scopes {
+				if !principal.HasScope(scope) {
+					resp := problemResponse(http.StatusForbidden, fmt.Sprintf("missing scope '%s'", scope))
+					resp.Headers["WWW-Authenticate"] = fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(route.Scopes, " "))
+					return resp, nil
+				}
+			}
+
+			return next(auth.NewContext(ctx, principal), event)
+		}
+	}
+}
+
+// AuthorizeFromEnv returns the authorization middleware of the AUTH_MODE
+// environment variable. API Gateway attaches no authorizer to the routes, so
+// the functions authorize requests themselves. With "jwt", bearer tokens are
+// verified against the keys given by JWKS_FILE or JWKS_URL, which must be set.
+// With "apikey", the X-Api-Key header is checked against apiKeys, which must
+// not be nil. With "none" or no mode, only the requests let through by an API
+// Gateway authorizer, like the ApiKeyAuthorizer of the partner API, have their
+// scopes checked, and the others pass through.
+func AuthorizeFromEnv(routes []Route, apiKeys types.APIKeyStore) (Middleware, error) {
+	switch authMode := os.Getenv("AUTH_MODE"); authMode {
+	case "", "none":
+		authorize := Authorize(routes, nil)
+		return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+			authorized := authorize(next)
+			return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+				if authorizer := event.RequestContext.Authorizer; authorizer != nil && (authorizer.JWT != nil || authorizer.Lambda != nil) {
+					return authorized(ctx, event)
+				}
+
+				return next(ctx, event)
+			}
+		}, nil
+	case "jwt":
+		verifier, err := auth.NewJWTVerifierFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("unable to load JWT verifier: %w", err)
+		}
+		if verifier == nil {
+			return nil, errors.New("need JWKS_FILE or JWKS_URL environment variable to verify bearer tokens")
+		}
+
+		return Authorize(routes, verifier), nil
+	case "apikey":
+		if apiKeys == nil {
+			return nil, errors.New("need API_KEYS_TABLE environment variable to check API keys")
+		}
+
+		authorizer := NewAPIKeyAuthorizerHandler(domain.NewAPIKeysDomain(apiKeys))
+		return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+			return authorizer.Emulate(routes)(Authorize(routes, nil)(next))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown AUTH_MODE '%s'", authMode)
+	}
+}
+
+var errMissingCredentials = errors.New("missing bearer token")
+
+func principalFromRequest(event events.APIGatewayV2HTTPRequest, verifier *auth.JWTVerifier) (auth.Principal, error) {
+	if authorizer := event.RequestContext.Authorizer; authorizer != nil && authorizer.JWT != nil {
+		claims := auth.Claims{}
+		for name, value := range authorizer.JWT.Claims {
+			claims[name] = value
+		}
+
+		scopes := authorizer.JWT.Scopes
+		if len(scopes) == 0 {
+			scopes = claims.Scopes()
+		}
+
+		return auth.Principal{Subject: claims.Subject(), Scopes: scopes, Method: "jwt"}, nil
+	}
+
+	if authorizer := event.RequestContext.Authorizer; authorizer != nil && authorizer.Lambda != nil {
+		subject, _ := authorizer.Lambda["principalId"].(string)
+		scopes, _ := authorizer.Lambda["scopes"].(string)
+
+		return auth.Principal{Subject: subject, Scopes: strings.Fields(scopes), Method: "apikey"}, nil
+	}
+
+	if verifier == nil {
+		return auth.Principal{}, errMissingCredentials
+	}
+
+	authorization, _ := header(event, "Authorization")
+	token := strings.TrimSpace(authorization)
+	if len(token) < 7 || !strings.EqualFold(token[:7], "Bearer ") {
+		return auth.Principal{}, errMissingCredentials
+	}
+
+	claims, err := verifier.Verify(strings.TrimSpace(token[7:]))
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	return auth.Principal{Subject: claims.Subject(), Scopes: claims.Scopes(), Method: "jwt"}, nil
+}
@@ -0,0 +1,136 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws-samples/serverless-go-demo/auth"
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/store"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+
+	var principal auth.Principal
+	handler := func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		principal, _ = auth.FromContext(ctx)
+		return response(http.StatusOK, nil), nil
+	}
+
+	routes := []Route{
+		{Method: http.MethodGet, Path: "/{id}", Handler: handler, Scopes: []string{ScopeProductsRead}},
+		{Method: http.MethodPut, Path: "/{id}", Handler: handler, Scopes: []string{ScopeProductsWrite}},
+		{Method: http.MethodGet, Path: "/openapi.json", Handler: handler},
+	}
+	authorize := Authorize(routes, nil)(NewRouter(routes...).Handler)
+
+	request := func(method string, path string, scopes ...string) events.APIGatewayV2HTTPRequest {
+		event := routedRequest("$default", method, path)
+		if scopes != nil {
+			event.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+					Claims: map[string]string{"sub": "alice"},
+					Scopes: scopes,
+				},
+			}
+		}
+		return event
+	}
+
+	tests := []struct {
+		name   string
+		event  events.APIGatewayV2HTTPRequest
+		status int
+	}{
+		{"without credentials", request(http.MethodGet, "/1"), http.StatusUnauthorized},
+		{"with the read scope", request(http.MethodGet, "/1", ScopeProductsRead), http.StatusOK},
+		{"writing with the read scope", request(http.MethodPut, "/1", ScopeProductsRead), http.StatusForbidden},
+		{"writing with the write scope", request(http.MethodPut, "/1", ScopeProductsWrite), http.StatusOK},
+		{"on a public route", request(http.MethodGet, "/openapi.json"), http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, _ := authorize(ctx, test.event)
+			if resp.StatusCode != test.status {
+				t.Errorf("Got status %d, expected %d: %s", resp.StatusCode, test.status, resp.Body)
+			}
+
+			if resp.StatusCode >= 400 && resp.Headers["Content-Type"] != "application/problem+json" {
+				t.Errorf("Got content type %q, expected a problem response", resp.Headers["Content-Type"])
+			}
+		})
+	}
+
+	authorize(ctx, request(http.MethodGet, "/1", ScopeProductsRead))
+	if principal.Subject != "alice" {
+		t.Errorf("Got principal %v in the context", principal)
+	}
+}
+
+func TestAuthorizeFromEnv(t *testing.T) {
+	routes := []Route{{Method: http.MethodGet, Path: "/{id}", Scopes: []string{ScopeProductsRead}}}
+	handler := func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		return response(http.StatusOK, nil), nil
+	}
+
+	t.Setenv("JWKS_FILE", "")
+	t.Setenv("JWKS_URL", "")
+
+	t.Setenv("AUTH_MODE", "none")
+	authorize, err := AuthorizeFromEnv(routes, nil)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %s", err)
+	}
+	if resp, _ := authorize(handler)(context.Background(), routedRequest("$default", http.MethodGet, "/1")); resp.StatusCode != http.StatusOK {
+		t.Errorf("Got status %d without authorization, expected 200", resp.StatusCode)
+	}
+
+	// Requests let through by the authorizer of the partner API still need
+	// the scopes of the route.
+	partnerRequest := routedRequest("$default", http.MethodGet, "/1")
+	partnerRequest.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+		Lambda: map[string]interface{}{"principalId": "key", "scopes": ScopeProductsWrite},
+	}
+	if resp, _ := authorize(handler)(context.Background(), partnerRequest); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Got status %d for an API key without the read scope, expected 403", resp.StatusCode)
+	}
+
+	t.Setenv("AUTH_MODE", "jwt")
+	if _, err := AuthorizeFromEnv(routes, nil); err == nil {
+		t.Errorf("Expected an error for the jwt mode without keys")
+	}
+
+	t.Setenv("AUTH_MODE", "apikey")
+	if _, err := AuthorizeFromEnv(routes, nil); err == nil {
+		t.Errorf("Expected an error for the apikey mode without a key store")
+	}
+
+	apiKeyStore := store.NewMemoryAPIKeyStore()
+	issued, _ := domain.NewAPIKeysDomain(apiKeyStore).Issue(context.Background(), "partner", []string{ScopeProductsRead}, nil)
+	authorize, err = AuthorizeFromEnv(routes, apiKeyStore)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %s", err)
+	}
+
+	withKey := routedRequest("$default", http.MethodGet, "/1")
+	withKey.Headers = map[string]string{APIKeyHeader: issued.Secret}
+	if resp, _ := authorize(handler)(context.Background(), withKey); resp.StatusCode != http.StatusOK {
+		t.Errorf("Got status %d with an API key, expected 200", resp.StatusCode)
+	}
+	if resp, _ := authorize(handler)(context.Background(), routedRequest("$default", http.MethodGet, "/1")); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Got status %d without an API key, expected 401", resp.StatusCode)
+	}
+
+	t.Setenv("AUTH_MODE", "basic")
+	if _, err := AuthorizeFromEnv(routes, nil); err == nil {
+		t.Errorf("Expected an error for an unknown mode")
+	}
+}
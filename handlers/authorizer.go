@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const APIKeyHeader = "X-Api-Key"
+
+// apiKeyChallenge tells clients denied for lack of credentials where to send
+// their API key.
+const apiKeyChallenge = `ApiKey header="X-Api-Key"`
+
+// Can be deleted when aws-lambda-go ships the payload format 2.0 authorizer request.
+
+type APIGatewayV2CustomAuthorizerV2Request struct {
+	Version               string                                `json:"version"`
+	Type                  string                                `json:"type"`
+	RouteArn              string                                `json:"routeArn"`
+	IdentitySource        []string                              `json:"identitySource"`
+	RouteKey              string                                `json:"routeKey"`
+	RawPath               string                                `json:"rawPath"`
+	RawQueryString        string                                `json:"rawQueryString"`
+	Cookies               []string                              `json:"cookies"`
+	Headers               map[string]string                     `json:"headers"`
+	QueryStringParameters map[string]string                     `json:"queryStringParameters"`
+	RequestContext        events.APIGatewayV2HTTPRequestContext `json:"requestContext"`
+	PathParameters        map[string]string                     `json:"pathParameters"`
+	StageVariables        map[string]string                     `json:"stageVariables"`
+}
+
+type APIKeyAuthorizerHandler struct {
+	apiKeys *domain.APIKeys
+}
+
+func NewAPIKeyAuthorizerHandler(d *domain.APIKeys) *APIKeyAuthorizerHandler {
+	return &APIKeyAuthorizerHandler{
+		apiKeys: d,
+	}
+}
+
+// Handler is an HTTP API Lambda authorizer using simple responses. Authorized
+// requests reach the functions with the key id, owner and scopes in
+// requestContext.authorizer.lambda, where Authorize reads them.
+func (a *APIKeyAuthorizerHandler) Handler(ctx context.Context, event APIGatewayV2CustomAuthorizerV2Request) (events.APIGatewayV2CustomAuthorizerSimpleResponse, error) {
+	secret := ""
+	for name, value := range event.Headers {
+		if strings.EqualFold(name, APIKeyHeader) {
+			secret = value
+		}
+	}
+
+	key, err := a.apiKeys.Authenticate(ctx, secret)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyInvalid) || errors.Is(err, domain.ErrAPIKeyExpired) || errors.Is(err, domain.ErrAPIKeyRevoked) {
+			return events.APIGatewayV2CustomAuthorizerSimpleResponse{IsAuthorized: false}, nil
+		}
+
+		// Returning an error makes API Gateway answer 500 instead of denying valid keys.
+		log.Printf("cannot authenticate API key: %v", err)
+		return events.APIGatewayV2CustomAuthorizerSimpleResponse{}, err
+	}
+
+	return events.APIGatewayV2CustomAuthorizerSimpleResponse{
+		IsAuthorized: true,
+		Context: map[string]interface{}{
+			"principalId": key.Id,
+			"owner":       key.Owner,
+			"scopes":      strings.Join(key.Scopes, " "),
+		},
+	}, nil
+}
+
+// Emulate runs the authorizer in front of the routes with scopes the way API
+// Gateway would, for functions behind routes without an authorizer and for
+// local runs where there is no API Gateway. Requests without an API key but
+// with an Authorization header are passed on untouched, for Authorize to
+// check their bearer token.
+func (a *APIKeyAuthorizerHandler) Emulate(routes []Route) Middleware {
+	router := NewRouter(routes...)
+
+	return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+		return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			route, _, _ := router.match(event)
+			if route == nil || len(route.Scopes) == 0 {
+				return next(ctx, event)
+			}
+
+			if _, ok := header(event, APIKeyHeader); !ok {
+				if _, ok := header(event, "Authorization"); ok {
+					return next(ctx, event)
+				}
+
+				resp := problemResponse(http.StatusUnauthorized, "missing API key")
+				resp.Headers["WWW-Authenticate"] = apiKeyChallenge
+				return resp, nil
+			}
+
+			result, err := a.Handler(ctx, APIGatewayV2CustomAuthorizerV2Request{
+				Version:        "2.0",
+				Type:           "REQUEST",
+				RouteKey:       route.Key(),
+				RawPath:        event.RawPath,
+				Headers:        event.Headers,
+				RequestContext: event.RequestContext,
+			})
+			if err != nil {
+				return problemResponse(http.StatusInternalServerError, "cannot authenticate API key"), nil
+			}
+
+			if !result.IsAuthorized {
+				return problemResponse(http.StatusForbidden, "invalid, expired or revoked API key"), nil
+			}
+
+			event.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				Lambda: result.Context,
+			}
+
+			return next(ctx, event)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/auth"
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/store"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestEmulateAnswersProblems(t *testing.T) {
+	ctx := context.Background()
+	handler := func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		return response(http.StatusOK, nil), nil
+	}
+
+	apiKeys := domain.NewAPIKeysDomain(store.NewMemoryAPIKeyStore())
+	issued, _ := apiKeys.Issue(ctx, "partner", []string{ScopeProductsRead}, nil)
+
+	routes := []Route{{Method: http.MethodGet, Path: "/{id}", Handler: handler, Scopes: []string{ScopeProductsRead}}}
+	emulate := NewAPIKeyAuthorizerHandler(apiKeys).Emulate(routes)(NewRouter(routes...).Handler)
+
+	request := func(key string) events.APIGatewayV2HTTPRequest {
+		event := routedRequest("$default", http.MethodGet, "/1")
+		if key != "" {
+			event.Headers = map[string]string{APIKeyHeader: key}
+		}
+		return event
+	}
+
+	tests := []struct {
+		name   string
+		event  events.APIGatewayV2HTTPRequest
+		status int
+	}{
+		{"without a key", request(""), http.StatusUnauthorized},
+		{"with an unknown key", request("unknown"), http.StatusForbidden},
+		{"with a valid key", request(issued.Secret), http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, _ := emulate(ctx, test.event)
+			if resp.StatusCode != test.status {
+				t.Errorf("Got status %d, expected %d: %s", resp.StatusCode, test.status, resp.Body)
+			}
+
+			if resp.StatusCode >= 400 && resp.Headers["Content-Type"] != "application/problem+json" {
+				t.Errorf("Got content type %q, expected a problem response", resp.Headers["Content-Type"])
+			}
+
+			if resp.StatusCode == http.StatusUnauthorized && resp.Headers["WWW-Authenticate"] == "" {
+				t.Errorf("Got no WWW-Authenticate header on a 401")
+			}
+		})
+	}
+}
+
+// TestEmulateLetsBearerTokensThrough runs the API key authorizer in front of
+// the bearer token check, like the local server started with both -jwks and
+// -api-keys.
+func TestEmulateLetsBearerTokensThrough(t *testing.T) {
+	ctx := context.Background()
+
+	var principal auth.Principal
+	handler := func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		principal, _ = auth.FromContext(ctx)
+		return response(http.StatusOK, nil), nil
+	}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	verifier := auth.NewJWTVerifier(&auth.JWKS{Keys: []auth.JWK{{
+		Kty: "RSA",
+		Kid: "rsa",
+		N:   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}}}, "", "")
+
+	apiKeys := domain.NewAPIKeysDomain(store.NewMemoryAPIKeyStore())
+	issued, _ := apiKeys.Issue(ctx, "partner", []string{ScopeProductsRead}, nil)
+
+	routes := []Route{{Method: http.MethodGet, Path: "/{id}", Handler: handler, Scopes: []string{ScopeProductsRead}}}
+	fn := Authorize(routes, verifier)(NewRouter(routes...).Handler)
+	fn = NewAPIKeyAuthorizerHandler(apiKeys).Emulate(routes)(fn)
+
+	withToken := routedRequest("$default", http.MethodGet, "/1")
+	withToken.Headers = map[string]string{"Authorization": "Bearer " + signToken(t, rsaKey, "rsa", auth.Claims{
+		"sub":   "alice",
+		"exp":   float64(time.Now().Add(time.Hour).Unix()),
+		"scope": ScopeProductsRead,
+	})}
+	if resp, _ := fn(ctx, withToken); resp.StatusCode != http.StatusOK || principal.Subject != "alice" {
+		t.Errorf("Got status %d and principal %+v with a bearer token, expected alice to be let through", resp.StatusCode, principal)
+	}
+
+	withKey := routedRequest("$default", http.MethodGet, "/1")
+	withKey.Headers = map[string]string{APIKeyHeader: issued.Secret}
+	if resp, _ := fn(ctx, withKey); resp.StatusCode != http.StatusOK || principal.Subject != issued.Id {
+		t.Errorf("Got status %d and principal %+v with an API key, expected the key to be let through", resp.StatusCode, principal)
+	}
+
+	withBadToken := routedRequest("$default", http.MethodGet, "/1")
+	withBadToken.Headers = map[string]string{"Authorization": "Bearer not-a-token"}
+	if resp, _ := fn(ctx, withBadToken); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Got status %d with an invalid bearer token, expected 401", resp.StatusCode)
+	}
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims auth.Claims) string {
+	segment := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signingInput := segment(map[string]string{"alg": "RS256", "kid": kid}) + "." + segment(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("cannot sign token: %s", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	DefaultCompressionThreshold = 1024
+
+	// Lambda can't receive payloads bigger than 6 MB, so a request body that
+	// inflates past that is rejected instead of exhausting the memory.
+	maxDecompressedBodySize = 6 * 1024 * 1024
+
+	encodingBrotli   = "br"
+	encodingGzip     = "gzip"
+	encodingIdentity = "identity"
+)
+
+// Compress decompresses gzip and Brotli request bodies and compresses response
+// bodies of at least threshold bytes with the best encoding the client accepts.
+func Compress(threshold int) Middleware {
+	return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+		return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			event, err := decompressRequest(event)
+			if err != nil {
+				if errors.Is(err, errUnsupportedEncoding) {
+					return errResponse(http.StatusUnsupportedMediaType, err.Error()), nil
+				} else if errors.Is(err, errBodyTooLarge) {
+					return errResponse(http.StatusRequestEntityTooLarge, err.Error()), nil
+				} else {
+					return errResponse(http.StatusBadRequest, err.Error()), nil
+				}
+			}
+
+			resp, err := next(ctx, event)
+			if err != nil {
+				return resp, err
+			}
+
+			acceptEncoding, _ := header(event, "Accept-Encoding")
+
+			return compressResponse(resp, acceptEncoding, threshold), nil
+		}
+	}
+}
+
+var (
+	errUnsupportedEncoding = fmt.Errorf("unsupported 'Content-Encoding', use %s or %s", encodingGzip, encodingBrotli)
+	errBodyTooLarge        = fmt.Errorf("decompressed request body is larger than %d bytes", maxDecompressedBodySize)
+)
+
+func decompressRequest(event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPRequest, error) {
+	encoding, ok := header(event, "Content-Encoding")
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	if !ok || encoding == "" || encoding == encodingIdentity {
+		return event, nil
+	}
+
+	body, err := requestBody(event)
+	if err != nil {
+		return event, err
+	}
+
+	var reader io.Reader
+	switch encoding {
+	case encodingGzip:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return event, fmt.Errorf("invalid gzip request body: %w", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case encodingBrotli:
+		reader = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return event, errUnsupportedEncoding
+	}
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxDecompressedBodySize+1))
+	if err != nil {
+		return event, fmt.Errorf("invalid %s request body: %w", encoding, err)
+	}
+
+	if len(decompressed) > maxDecompressedBodySize {
+		return event, errBodyTooLarge
+	}
+
+	headers := make(map[string]string, len(event.Headers))
+	for name, value := range event.Headers {
+		if !strings.EqualFold(name, "Content-Encoding") && !strings.EqualFold(name, "Content-Length") {
+			headers[name] = value
+		}
+	}
+	event.Headers = headers
+
+	if utf8.Valid(decompressed) {
+		event.Body = string(decompressed)
+		event.IsBase64Encoded = false
+	} else {
+		event.Body = base64.StdEncoding.EncodeToString(decompressed)
+		event.IsBase64Encoded = true
+	}
+
+	return event, nil
+}
+
+func compressResponse(resp events.APIGatewayV2HTTPResponse, acceptEncoding string, threshold int) events.APIGatewayV2HTTPResponse {
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return resp
+	}
+
+	for name := range resp.Headers {
+		if strings.EqualFold(name, "Content-Encoding") {
+			return resp
+		}
+	}
+
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			return resp
+		}
+		body = decoded
+	}
+
+	if len(body) < threshold {
+		return resp
+	}
+
+	resp.Headers = withVary(resp.Headers, "Accept-Encoding")
+
+	encoding := negotiateEncoding(acceptEncoding)
+	if encoding == "" {
+		return resp
+	}
+
+	var compressed bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case encodingBrotli:
+		writer = brotli.NewWriterLevel(&compressed, brotli.DefaultCompression)
+	case encodingGzip:
+		writer = gzip.NewWriter(&compressed)
+	}
+
+	if _, err := writer.Write(body); err != nil {
+		return resp
+	}
+	if err := writer.Close(); err != nil {
+		return resp
+	}
+
+	if compressed.Len() >= len(body) {
+		return resp
+	}
+
+	resp.Headers["Content-Encoding"] = encoding
+	resp.Body = base64.StdEncoding.EncodeToString(compressed.Bytes())
+	resp.IsBase64Encoded = true
+
+	return resp
+}
+
+// negotiateEncoding picks the encoding with the highest quality value in an
+// Accept-Encoding header, preferring Brotli over gzip on ties. It returns an
+// empty string when the response should not be compressed.
+func negotiateEncoding(acceptEncoding string) string {
+	type candidate struct {
+		encoding string
+		quality  float64
+		rank     int
+	}
+
+	ranks := map[string]int{encodingBrotli: 0, encodingGzip: 1}
+	qualities := map[string]float64{}
+	wildcard := -1.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if name == "*" {
+			wildcard = quality
+			continue
+		}
+
+		qualities[name] = quality
+	}
+
+	candidates := []candidate{}
+	for encoding, rank := range ranks {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = wildcard
+		}
+
+		if quality > 0 {
+			candidates = append(candidates, candidate{encoding, quality, rank})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].quality != candidates[j].quality {
+			return candidates[i].quality > candidates[j].quality
+		}
+		return candidates[i].rank < candidates[j].rank
+	})
+
+	return candidates[0].encoding
+}
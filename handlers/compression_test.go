@@ -0,0 +1,98 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                      "",
+		"gzip":                  "gzip",
+		"gzip, br":              "br",
+		"br;q=0.5, gzip":        "gzip",
+		"*":                     "br",
+		"*;q=0.5, br;q=0":       "gzip",
+		"identity":              "",
+		"deflate, gzip;q=0.001": "gzip",
+	}
+
+	for acceptEncoding, expected := range tests {
+		if encoding := negotiateEncoding(acceptEncoding); encoding != expected {
+			t.Errorf("negotiateEncoding(%q) = %q, expected %q", acceptEncoding, encoding, expected)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	ctx := context.Background()
+	largeBody := strings.Repeat(`{"id":"1","name":"product","price":1}`, 100)
+
+	var received string
+	handler := Compress(DefaultCompressionThreshold)(func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		body, _ := requestBody(event)
+		received = string(body)
+		return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusOK, Headers: map[string]string{}, Body: largeBody}, nil
+	})
+
+	var compressedRequest bytes.Buffer
+	gzipWriter := gzip.NewWriter(&compressedRequest)
+	gzipWriter.Write([]byte(`{"id":"1"}`))
+	gzipWriter.Close()
+
+	resp, err := handler(ctx, events.APIGatewayV2HTTPRequest{
+		Headers: map[string]string{
+			"content-encoding": "gzip",
+			"accept-encoding":  "gzip, br",
+		},
+		Body:            base64.StdEncoding.EncodeToString(compressedRequest.Bytes()),
+		IsBase64Encoded: true,
+	})
+	if err != nil {
+		t.Fatalf("Got unexpected error: %s", err)
+	}
+
+	if received != `{"id":"1"}` {
+		t.Errorf("Handler received body %q", received)
+	}
+
+	if !resp.IsBase64Encoded || resp.Headers["Content-Encoding"] != "br" || resp.Headers["Vary"] != "Accept-Encoding" {
+		t.Fatalf("Got unexpected response headers %v", resp.Headers)
+	}
+
+	compressed, _ := base64.StdEncoding.DecodeString(resp.Body)
+	decompressed, _ := io.ReadAll(brotli.NewReader(bytes.NewReader(compressed)))
+	if string(decompressed) != largeBody {
+		t.Errorf("Decompressed body does not match the original")
+	}
+
+	t.Run("with a small body", func(t *testing.T) {
+		resp := compressResponse(response(http.StatusOK, "small"), "gzip", DefaultCompressionThreshold)
+		if resp.IsBase64Encoded || resp.Headers["Content-Encoding"] != "" {
+			t.Errorf("Small body should not be compressed")
+		}
+	})
+
+	t.Run("with an unsupported request encoding", func(t *testing.T) {
+		resp, _ := handler(ctx, events.APIGatewayV2HTTPRequest{
+			Headers: map[string]string{"content-encoding": "compress"},
+			Body:    "x",
+		})
+		if resp.StatusCode != http.StatusUnsupportedMediaType {
+			t.Errorf("Got status %d, expected %d", resp.StatusCode, http.StatusUnsupportedMediaType)
+		}
+	})
+}
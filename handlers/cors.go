@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CORSConfig tells which cross-origin browser requests are allowed. Origins
+// are matched exactly, except for "*", which allows any origin, and patterns
+// such as "https://*.example.com", which allow any subdomain.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// DefaultCORSConfig allows the methods and headers used by the API, and
+// exposes the headers the middlewares add to responses. It has no allowed
+// origin, so CORS stays disabled until one is set.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "Content-Encoding", APIKeyHeader, IdempotencyKeyHeader},
+		ExposedHeaders: []string{
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After",
+			IdempotentReplayedHeader, "WWW-Authenticate",
+		},
+		MaxAge: time.Hour,
+	}
+}
+
+// CORSConfigFromEnv reads the comma-separated lists CORS_ALLOWED_ORIGINS,
+// CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS and CORS_EXPOSED_HEADERS, the
+// boolean CORS_ALLOW_CREDENTIALS and CORS_MAX_AGE in seconds on top of
+// DefaultCORSConfig.
+func CORSConfigFromEnv() (CORSConfig, error) {
+	config := DefaultCORSConfig()
+
+	if origins := splitList(os.Getenv("CORS_ALLOWED_ORIGINS")); len(origins) > 0 {
+		config.AllowedOrigins = origins
+	}
+	if methods := splitList(os.Getenv("CORS_ALLOWED_METHODS")); len(methods) > 0 {
+		config.AllowedMethods = methods
+	}
+	if headers := splitList(os.Getenv("CORS_ALLOWED_HEADERS")); len(headers) > 0 {
+		config.AllowedHeaders = headers
+	}
+	if headers := splitList(os.Getenv("CORS_EXPOSED_HEADERS")); len(headers) > 0 {
+		config.ExposedHeaders = headers
+	}
+
+	if credentials := os.Getenv("CORS_ALLOW_CREDENTIALS"); credentials != "" {
+		allow, err := strconv.ParseBool(credentials)
+		if err != nil {
+			return CORSConfig{}, fmt.Errorf("invalid CORS_ALLOW_CREDENTIALS: %w", err)
+		}
+		config.AllowCredentials = allow
+	}
+
+	if maxAge := os.Getenv("CORS_MAX_AGE"); maxAge != "" {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || seconds < 0 {
+			return CORSConfig{}, fmt.Errorf("invalid CORS_MAX_AGE '%s'", maxAge)
+		}
+		config.MaxAge = time.Duration(seconds) * time.Second
+	}
+
+	return config, nil
+}
+
+// CORS answers preflight requests from allowed origins itself and adds the
+// Access-Control-* headers to the responses of their actual requests. It must
+// be the outermost middleware, so that preflights don't need credentials and
+// error responses can still be read by the browser. Requests from other
+// origins get no CORS headers, and browsers then block them.
+func CORS(config CORSConfig) Middleware {
+	return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+		if len(config.AllowedOrigins) == 0 {
+			return next
+		}
+
+		return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			origin, _ := header(event, "Origin")
+			requestMethod, isPreflight := header(event, "Access-Control-Request-Method")
+			isPreflight = isPreflight && event.RequestContext.HTTP.Method == http.MethodOptions
+
+			if isPreflight {
+				return config.preflightResponse(event, origin, requestMethod), nil
+			}
+
+			resp, err := next(ctx, event)
+			if err != nil || origin == "" {
+				return resp, err
+			}
+
+			resp.Headers = withVary(resp.Headers, "Origin")
+			if !config.allowsOrigin(origin) {
+				return resp, nil
+			}
+
+			config.setOriginHeaders(resp.Headers, origin)
+			if len(config.ExposedHeaders) > 0 {
+				resp.Headers["Access-Control-Expose-Headers"] = strings.Join(config.ExposedHeaders, ", ")
+			}
+
+			return resp, nil
+		}
+	}
+}
+
+func (c CORSConfig) preflightResponse(event events.APIGatewayV2HTTPRequest, origin string, requestMethod string) events.APIGatewayV2HTTPResponse {
+	if !c.allowsOrigin(origin) {
+		return problemResponse(http.StatusForbidden, fmt.Sprintf("origin '%s' is not allowed", origin))
+	}
+
+	if !containsFold(c.AllowedMethods, requestMethod) {
+		return problemResponse(http.StatusForbidden, fmt.Sprintf("method '%s' is not allowed", requestMethod))
+	}
+
+	resp := events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusNoContent,
+		Headers: map[string]string{
+			"Access-Control-Allow-Methods": strings.Join(c.AllowedMethods, ", "),
+			"Vary":                         "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+		},
+	}
+	c.setOriginHeaders(resp.Headers, origin)
+
+	allowedHeaders := strings.Join(c.AllowedHeaders, ", ")
+	if containsFold(c.AllowedHeaders, "*") {
+		// Browsers only honor "*" without credentials, so the requested headers
+		// are echoed back instead.
+		allowedHeaders, _ = header(event, "Access-Control-Request-Headers")
+	}
+	if allowedHeaders != "" {
+		resp.Headers["Access-Control-Allow-Headers"] = allowedHeaders
+	}
+
+	if c.MaxAge > 0 {
+		resp.Headers["Access-Control-Max-Age"] = strconv.Itoa(int(c.MaxAge.Seconds()))
+	}
+
+	return resp
+}
+
+func (c CORSConfig) setOriginHeaders(headers map[string]string, origin string) {
+	if !c.AllowCredentials && len(c.AllowedOrigins) == 1 && c.AllowedOrigins[0] == "*" {
+		headers["Access-Control-Allow-Origin"] = "*"
+		return
+	}
+
+	headers["Access-Control-Allow-Origin"] = origin
+	if c.AllowCredentials {
+		headers["Access-Control-Allow-Credentials"] = "true"
+	}
+}
+
+func (c CORSConfig) allowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		// "https://*.example.com" allows "https://a.example.com" and
+		// "https://a.b.example.com" but not "https://example.com".
+		if i := strings.Index(allowed, "://*."); i >= 0 {
+			scheme, suffix := allowed[:i+3], allowed[i+4:]
+			if len(origin) > len(scheme)+len(suffix) &&
+				strings.EqualFold(origin[:len(scheme)], scheme) &&
+				strings.EqualFold(origin[len(origin)-len(suffix):], suffix) &&
+				!strings.Contains(origin[len(scheme):len(origin)-len(suffix)], "/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func splitList(s string) []string {
+	values := []string{}
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func containsFold(values []string, s string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func corsRequest(method string, headers map[string]string) events.APIGatewayV2HTTPRequest {
+	return events.APIGatewayV2HTTPRequest{
+		RawPath: "/1",
+		Headers: headers,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: method},
+		},
+	}
+}
+
+func TestCORSConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://admin.example.com, https://*.example.org")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("CORS_MAX_AGE", "600")
+
+	config, err := CORSConfigFromEnv()
+	if err != nil {
+		t.Fatalf("CORSConfigFromEnv returned an error: %s", err)
+	}
+
+	if len(config.AllowedOrigins) != 2 || !config.AllowCredentials || config.MaxAge.Seconds() != 600 {
+		t.Errorf("Got unexpected config %+v", config)
+	}
+
+	t.Setenv("CORS_MAX_AGE", "soon")
+	if _, err := CORSConfigFromEnv(); err == nil {
+		t.Errorf("Expected an error for an invalid CORS_MAX_AGE")
+	}
+}
+
+func TestCORSAllowsOrigin(t *testing.T) {
+	config := CORSConfig{AllowedOrigins: []string{"https://admin.example.com", "https://*.example.org"}}
+
+	tests := map[string]bool{
+		"https://admin.example.com":  true,
+		"https://ADMIN.example.com":  true,
+		"http://admin.example.com":   false,
+		"https://a.example.org":      true,
+		"https://a.b.example.org":    true,
+		"https://example.org":        false,
+		"https://evil-example.org":   false,
+		"https://a.example.org.evil": false,
+		"":                           false,
+	}
+
+	for origin, expected := range tests {
+		if got := config.allowsOrigin(origin); got != expected {
+			t.Errorf("allowsOrigin(%q) = %t, expected %t", origin, got, expected)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	calls := 0
+	next := func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		calls++
+		return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusOK, Headers: map[string]string{"Vary": "Accept-Encoding"}}, nil
+	}
+
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"https://*.example.com"}
+	config.AllowCredentials = true
+	fn := CORS(config)(next)
+
+	t.Run("preflight from an allowed origin", func(t *testing.T) {
+		resp, _ := fn(context.Background(), corsRequest(http.MethodOptions, map[string]string{
+			"origin":                        "https://admin.example.com",
+			"access-control-request-method": http.MethodPut,
+		}))
+
+		if resp.StatusCode != http.StatusNoContent || calls != 0 {
+			t.Fatalf("Got status %d and %d calls, expected 204 without calling the handler", resp.StatusCode, calls)
+		}
+
+		if resp.Headers["Access-Control-Allow-Origin"] != "https://admin.example.com" ||
+			resp.Headers["Access-Control-Allow-Credentials"] != "true" ||
+			resp.Headers["Access-Control-Max-Age"] != "3600" {
+			t.Errorf("Got unexpected preflight headers %v", resp.Headers)
+		}
+	})
+
+	t.Run("preflight from another origin", func(t *testing.T) {
+		resp, _ := fn(context.Background(), corsRequest(http.MethodOptions, map[string]string{
+			"origin":                        "https://evil.com",
+			"access-control-request-method": http.MethodPut,
+		}))
+
+		if resp.StatusCode != http.StatusForbidden || resp.Headers["Access-Control-Allow-Origin"] != "" {
+			t.Errorf("Got status %d and headers %v", resp.StatusCode, resp.Headers)
+		}
+	})
+
+	t.Run("actual request", func(t *testing.T) {
+		resp, _ := fn(context.Background(), corsRequest(http.MethodGet, map[string]string{"Origin": "https://admin.example.com"}))
+
+		if resp.Headers["Access-Control-Allow-Origin"] != "https://admin.example.com" || resp.Headers["Vary"] != "Accept-Encoding, Origin" {
+			t.Errorf("Got unexpected headers %v", resp.Headers)
+		}
+		if resp.Headers["Access-Control-Expose-Headers"] == "" {
+			t.Errorf("Missing Access-Control-Expose-Headers")
+		}
+	})
+
+	t.Run("actual request from another origin", func(t *testing.T) {
+		resp, _ := fn(context.Background(), corsRequest(http.MethodGet, map[string]string{"Origin": "https://evil.com"}))
+
+		if resp.StatusCode != http.StatusOK || resp.Headers["Access-Control-Allow-Origin"] != "" {
+			t.Errorf("Got status %d and headers %v", resp.StatusCode, resp.Headers)
+		}
+	})
+}
@@ -3,15 +3,47 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/aws-samples/serverless-go-demo/domain"
 	"github.com/aws-samples/serverless-go-demo/types"
+
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// DefaultMaxRecordAttempts is how many times a record can fail before it is
+// sent to the dead-letter sink.
+const DefaultMaxRecordAttempts = 3
+
+// FailureCodeInvalidRecord reports stream records that can't be read.
+const FailureCodeInvalidRecord = "InvalidRecord"
+
 type DynamoDBEventHandler struct {
 	productStream *domain.ProductsStream
+	deadLetters   types.DeadLetterSink
+	maxAttempts   int
+
+	// attempts counts the failures of each record by sequence number. It only
+	// lives as long as the execution environment, so records can be retried a
+	// few more times than maxAttempts after a cold start.
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+type DynamoDBEventHandlerOption func(*DynamoDBEventHandler)
+
+// WithDeadLetterSink sends records that failed maxAttempts times to the sink
+// instead of retrying them forever.
+func WithDeadLetterSink(s types.DeadLetterSink, maxAttempts int) DynamoDBEventHandlerOption {
+	return func(d *DynamoDBEventHandler) {
+		d.deadLetters = s
+		d.maxAttempts = maxAttempts
+	}
 }
 
 // Can be deleted when this is merged: https://github.com/aws/aws-lambda-go/pull/410/files
@@ -24,56 +56,220 @@ type BatchItemFailure struct {
 	ItemIdentifier string `json:"itemIdentifier"`
 }
 
-func NewDynamoDBEventHandler(p *domain.ProductsStream) *DynamoDBEventHandler {
-	return &DynamoDBEventHandler{
+func NewDynamoDBEventHandler(p *domain.ProductsStream, opts ...DynamoDBEventHandlerOption) *DynamoDBEventHandler {
+	d := &DynamoDBEventHandler{
 		productStream: p,
+		maxAttempts:   DefaultMaxRecordAttempts,
+		attempts:      map[string]int{},
 	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
+// recordFailure is why a record failed, along with the events built from it
+// that could not be published.
+type recordFailure struct {
+	code    string
+	message string
+	events  []types.Event
+}
+
+// StreamHandler never returns an error, which would make Lambda retry the
+// whole batch. Records that fail are reported by sequence number instead, so
+// that processing resumes from the first of them. When the bus is down, every
+// record is reported. Records that fail for reasons of their own are sent to
+// the dead-letter sink once they reach the maximum number of attempts.
 func (d *DynamoDBEventHandler) StreamHandler(ctx context.Context, event events.DynamoDBEvent) (StreamsEventResponse, error) {
-	internalEvents := make([]types.Event, len(event.Records))
-	for i, ddbEvent := range event.Records {
-		internalEvents[i] = eventFromDynamoDBRecord(ddbEvent)
+	changes := make([]types.ProductChange, 0, len(event.Records))
+	failures := map[string]*recordFailure{}
+
+	for _, record := range event.Records {
+		change, err := changeFromDynamoDBRecord(record)
+		if err != nil {
+			failures[record.EventID] = &recordFailure{code: FailureCodeInvalidRecord, message: err.Error()}
+			continue
+		}
+		changes = append(changes, change)
 	}
 
-	failedEvents, err := d.productStream.Publish(ctx, internalEvents)
+	failedEvents, err := d.productStream.Publish(ctx, changes)
 	if err != nil {
-		log.Fatalf("totally failed to publish: %v", err)
-		return StreamsEventResponse{}, err
-	}
+		log.Printf("failed to publish %d changes, retrying the whole batch: %v", len(changes), err)
 
-	if len(failedEvents) > 0 {
-		itemFailures := make([]BatchItemFailure, len(failedEvents))
-		for i, failedItem := range failedEvents {
-			itemFailures[i] = BatchItemFailure{ItemIdentifier: failedItem.Resources[0]}
+		itemFailures := make([]BatchItemFailure, len(event.Records))
+		for i, record := range event.Records {
+			itemFailures[i] = BatchItemFailure{ItemIdentifier: record.Change.SequenceNumber}
 		}
 
 		return StreamsEventResponse{BatchItemFailures: itemFailures}, nil
 	}
 
-	return StreamsEventResponse{}, nil
+	// A change can lead to several events, which all point to its record.
+	for _, failedEvent := range failedEvents {
+		if len(failedEvent.Resources) == 0 {
+			continue
+		}
+
+		failure, ok := failures[failedEvent.Resources[0]]
+		if !ok {
+			failure = &recordFailure{code: failedEvent.FailureCode, message: failedEvent.FailureMessage}
+			failures[failedEvent.Resources[0]] = failure
+		}
+		if failedEvent.DetailType != "" {
+			failure.events = append(failure.events, failedEvent.Event)
+		}
+	}
+
+	return StreamsEventResponse{BatchItemFailures: d.itemFailures(ctx, event.Records, failures)}, nil
 }
 
-func eventFromDynamoDBRecord(record events.DynamoDBEventRecord) types.Event {
-	change, err := json.Marshal(record.Change)
-	if err != nil {
-		log.Fatalf("cannot unmarshal dynamodb record change: %s", err)
+// itemFailures counts the attempts of the failed records, sends those that
+// reached the maximum to the dead-letter sink and returns the others, in the
+// order of the batch.
+func (d *DynamoDBEventHandler) itemFailures(ctx context.Context, records []events.DynamoDBEventRecord, failures map[string]*recordFailure) []BatchItemFailure {
+	itemFailures := []BatchItemFailure{}
+	deadLetters := []types.DeadLetter{}
+
+	d.mu.Lock()
+	for _, record := range records {
+		sequenceNumber := record.Change.SequenceNumber
+
+		failure, ok := failures[record.EventID]
+		if !ok {
+			delete(d.attempts, sequenceNumber)
+			continue
+		}
+
+		d.attempts[sequenceNumber]++
+		if d.deadLetters == nil || d.attempts[sequenceNumber] < d.maxAttempts {
+			itemFailures = append(itemFailures, BatchItemFailure{ItemIdentifier: sequenceNumber})
+			continue
+		}
+
+		rawRecord, _ := json.Marshal(record)
+		deadLetters = append(deadLetters, types.DeadLetter{
+			Id:             sequenceNumber,
+			FailureCode:    failure.code,
+			FailureMessage: failure.message,
+			Attempts:       d.attempts[sequenceNumber],
+			FailedAt:       time.Now().UTC(),
+			Events:         failure.events,
+			Record:         rawRecord,
+		})
+	}
+	d.mu.Unlock()
+
+	if len(deadLetters) == 0 {
+		return itemFailures
+	}
+
+	// Reporting the records again would retry them until they expire from the
+	// stream and hold back those behind them, so they are logged instead.
+	if err := d.deadLetters.Send(ctx, deadLetters); err != nil {
+		log.Printf("failed to send %d records to the dead-letter sink, logging them instead: %v", len(deadLetters), err)
+
+		for _, deadLetter := range deadLetters {
+			data, _ := json.Marshal(deadLetter)
+			log.Printf("DEAD_LETTER %s", data)
+		}
+	}
+
+	d.mu.Lock()
+	for _, deadLetter := range deadLetters {
+		delete(d.attempts, deadLetter.Id)
+	}
+	d.mu.Unlock()
+
+	return itemFailures
+}
+
+// changeFromDynamoDBRecord reads the products before and after the change from
+// the images of a stream record.
+func changeFromDynamoDBRecord(record events.DynamoDBEventRecord) (types.ProductChange, error) {
+	change := types.ProductChange{
+		Id:   record.EventID,
+		Time: record.Change.ApproximateCreationDateTime.UTC(),
 	}
 
-	detailType := ""
 	switch record.EventName {
 	case string(events.DynamoDBOperationTypeInsert):
-		detailType = "ProductCreated"
+		change.Type = types.ProductCreated
 	case string(events.DynamoDBOperationTypeModify):
-		detailType = "ProductUpdated"
+		change.Type = types.ProductUpdated
 	case string(events.DynamoDBOperationTypeRemove):
-		detailType = "ProductDelected"
+		change.Type = types.ProductDeleted
+	default:
+		return change, fmt.Errorf("unknown event name '%s'", record.EventName)
+	}
+
+	if len(record.Change.OldImage) > 0 {
+		before, err := productFromImage(record.Change.OldImage)
+		if err != nil {
+			return change, err
+		}
+		change.Before = &before
+	}
+
+	if len(record.Change.NewImage) > 0 {
+		after, err := productFromImage(record.Change.NewImage)
+		if err != nil {
+			return change, err
+		}
+		change.After = &after
+	}
+
+	return change, nil
+}
+
+func productFromImage(image map[string]events.DynamoDBAttributeValue) (types.Product, error) {
+	item := make(map[string]ddbtypes.AttributeValue, len(image))
+	for name, value := range image {
+		item[name] = attributeValueFromStream(value)
+	}
+
+	product := types.Product{}
+	if err := attributevalue.UnmarshalMap(item, &product); err != nil {
+		return product, err
 	}
 
-	return types.Event{
-		Source:     "serverless-go-demo",
-		Detail:     string(change),
-		DetailType: detailType,
-		Resources:  []string{record.EventID},
+	return product, nil
+}
+
+// attributeValueFromStream converts an attribute value of a Lambda stream
+// event to the SDK type, so that images can be unmarshaled like items.
+func attributeValueFromStream(value events.DynamoDBAttributeValue) ddbtypes.AttributeValue {
+	switch value.DataType() {
+	case events.DataTypeString:
+		return &ddbtypes.AttributeValueMemberS{Value: value.String()}
+	case events.DataTypeNumber:
+		return &ddbtypes.AttributeValueMemberN{Value: value.Number()}
+	case events.DataTypeBinary:
+		return &ddbtypes.AttributeValueMemberB{Value: value.Binary()}
+	case events.DataTypeBoolean:
+		return &ddbtypes.AttributeValueMemberBOOL{Value: value.Boolean()}
+	case events.DataTypeStringSet:
+		return &ddbtypes.AttributeValueMemberSS{Value: value.StringSet()}
+	case events.DataTypeNumberSet:
+		return &ddbtypes.AttributeValueMemberNS{Value: value.NumberSet()}
+	case events.DataTypeBinarySet:
+		return &ddbtypes.AttributeValueMemberBS{Value: value.BinarySet()}
+	case events.DataTypeList:
+		list := make([]ddbtypes.AttributeValue, len(value.List()))
+		for i, item := range value.List() {
+			list[i] = attributeValueFromStream(item)
+		}
+		return &ddbtypes.AttributeValueMemberL{Value: list}
+	case events.DataTypeMap:
+		m := make(map[string]ddbtypes.AttributeValue, len(value.Map()))
+		for name, item := range value.Map() {
+			m[name] = attributeValueFromStream(item)
+		}
+		return &ddbtypes.AttributeValueMemberM{Value: m}
+	default:
+		return &ddbtypes.AttributeValueMemberNULL{Value: true}
 	}
 }
@@ -0,0 +1,151 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestChangeFromDynamoDBRecord(t *testing.T) {
+	record := events.DynamoDBEventRecord{
+		EventID:   "c4ca4238a0b923820dcc509a6f75849b",
+		EventName: string(events.DynamoDBOperationTypeModify),
+		Change: events.DynamoDBStreamRecord{
+			ApproximateCreationDateTime: events.SecondsEpochTime{Time: time.Unix(1640995200, 0)},
+			OldImage: map[string]events.DynamoDBAttributeValue{
+				"id":    events.NewStringAttribute("iXR"),
+				"name":  events.NewStringAttribute("iPhone XML"),
+				"price": events.NewNumberAttribute("0.5"),
+			},
+			NewImage: map[string]events.DynamoDBAttributeValue{
+				"id":        events.NewStringAttribute("iXR"),
+				"name":      events.NewStringAttribute("iPhone XML"),
+				"price":     events.NewNumberAttribute("0.75"),
+				"updatedBy": events.NewStringAttribute("alice"),
+				"attributes": events.NewMapAttribute(map[string]events.DynamoDBAttributeValue{
+					"color": events.NewStringAttribute("red"),
+				}),
+			},
+		},
+	}
+
+	change, err := changeFromDynamoDBRecord(record)
+	if err != nil {
+		t.Fatalf("changeFromDynamoDBRecord returned an error: %s", err)
+	}
+
+	if change.Id != record.EventID || change.Type != types.ProductUpdated || !change.Time.Equal(time.Unix(1640995200, 0)) {
+		t.Errorf("Got unexpected change %+v", change)
+	}
+
+	if change.Before == nil || change.Before.Price != 0.5 {
+		t.Errorf("Got unexpected product before the change %+v", change.Before)
+	}
+
+	if change.After == nil || change.After.Price != 0.75 || change.After.UpdatedBy != "alice" || change.After.Attributes["color"] != "red" {
+		t.Errorf("Got unexpected product after the change %+v", change.After)
+	}
+}
+
+type stubBus struct {
+	err error
+}
+
+func (b *stubBus) Put(ctx context.Context, events []types.Event) ([]types.FailedEvent, error) {
+	return []types.FailedEvent{}, b.err
+}
+
+type recordingSink struct {
+	deadLetters []types.DeadLetter
+	err         error
+}
+
+func (s *recordingSink) Send(ctx context.Context, deadLetters []types.DeadLetter) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.deadLetters = append(s.deadLetters, deadLetters...)
+	return nil
+}
+
+func streamRecord(id string, sequenceNumber string, eventName string) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventID:   id,
+		EventName: eventName,
+		Change: events.DynamoDBStreamRecord{
+			SequenceNumber: sequenceNumber,
+			NewImage: map[string]events.DynamoDBAttributeValue{
+				"id": events.NewStringAttribute("iXR"),
+			},
+		},
+	}
+}
+
+func TestStreamHandlerReportsFailedRecords(t *testing.T) {
+	ctx := context.Background()
+	sink := &recordingSink{}
+	handler := NewDynamoDBEventHandler(domain.NewProductsStream(&stubBus{}), WithDeadLetterSink(sink, 2))
+
+	batch := events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord("1", "100", string(events.DynamoDBOperationTypeInsert)),
+		streamRecord("2", "200", "UNKNOWN"),
+	}}
+
+	resp, err := handler.StreamHandler(ctx, batch)
+	if err != nil {
+		t.Fatalf("StreamHandler returned an error: %s", err)
+	}
+	if len(resp.BatchItemFailures) != 1 || resp.BatchItemFailures[0].ItemIdentifier != "200" {
+		t.Fatalf("Got failures %+v, expected the sequence number of the bad record", resp.BatchItemFailures)
+	}
+
+	resp, _ = handler.StreamHandler(ctx, batch)
+	if len(resp.BatchItemFailures) != 0 {
+		t.Errorf("Got failures %+v once the bad record reached the maximum attempts", resp.BatchItemFailures)
+	}
+	if len(sink.deadLetters) != 1 || sink.deadLetters[0].Id != "200" || sink.deadLetters[0].FailureCode != FailureCodeInvalidRecord || sink.deadLetters[0].Attempts != 2 {
+		t.Errorf("Got unexpected dead letters %+v", sink.deadLetters)
+	}
+}
+
+func TestStreamHandlerRetriesBatchWhenBusFails(t *testing.T) {
+	sink := &recordingSink{}
+	handler := NewDynamoDBEventHandler(domain.NewProductsStream(&stubBus{err: errors.New("bus is down")}), WithDeadLetterSink(sink, 1))
+
+	resp, err := handler.StreamHandler(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord("1", "100", string(events.DynamoDBOperationTypeInsert)),
+		streamRecord("2", "200", string(events.DynamoDBOperationTypeInsert)),
+	}})
+	if err != nil {
+		t.Fatalf("StreamHandler returned an error: %s", err)
+	}
+
+	if len(resp.BatchItemFailures) != 2 || len(sink.deadLetters) != 0 {
+		t.Errorf("Got failures %+v and dead letters %+v, expected every record to be retried", resp.BatchItemFailures, sink.deadLetters)
+	}
+}
+
+func TestStreamHandlerDropsRecordsTheSinkRejects(t *testing.T) {
+	sink := &recordingSink{err: errors.New("item too large")}
+	handler := NewDynamoDBEventHandler(domain.NewProductsStream(&stubBus{}), WithDeadLetterSink(sink, 1))
+
+	resp, err := handler.StreamHandler(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord("1", "100", "UNKNOWN"),
+	}})
+	if err != nil {
+		t.Fatalf("StreamHandler returned an error: %s", err)
+	}
+
+	if len(resp.BatchItemFailures) != 0 {
+		t.Errorf("Got failures %+v, expected the record to be logged and dropped", resp.BatchItemFailures)
+	}
+}
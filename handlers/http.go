@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HTTPAdapter serves an APIGatewayV2HandlerFunc over plain net/http, turning
+// every request into the event API Gateway would have sent to the function.
+// It lets the handlers run locally without SAM, Docker or AWS.
+type HTTPAdapter struct {
+	handler APIGatewayV2HandlerFunc
+}
+
+var _ http.Handler = (*HTTPAdapter)(nil)
+
+func NewHTTPAdapter(h APIGatewayV2HandlerFunc) *HTTPAdapter {
+	return &HTTPAdapter{
+		handler: h,
+	}
+}
+
+func (a *HTTPAdapter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	event, err := EventFromHTTPRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := a.handler(r.Context(), event)
+	if err != nil {
+		// This is what API Gateway answers when the function returns an error.
+		log.Printf("handler returned an error: %v", err)
+		http.Error(w, `{"message":"Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeHTTPResponse(w, resp); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
+
+// EventFromHTTPRequest converts a net/http request into an API Gateway HTTP API
+// (payload version 2.0) request event. Path parameters are left to the Router.
+func EventFromHTTPRequest(r *http.Request) (events.APIGatewayV2HTTPRequest, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayV2HTTPRequest{}, err
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		if strings.EqualFold(name, "Cookie") {
+			continue
+		}
+
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	var cookies []string
+	for _, cookie := range r.Cookies() {
+		cookies = append(cookies, cookie.String())
+	}
+
+	var queryStringParameters map[string]string
+	if query := r.URL.Query(); len(query) > 0 {
+		queryStringParameters = make(map[string]string, len(query))
+		for name, values := range query {
+			queryStringParameters[name] = strings.Join(values, ",")
+		}
+	}
+
+	sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		sourceIP = r.RemoteAddr
+	}
+
+	now := time.Now()
+	event := events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RouteKey:              "$default",
+		RawPath:               r.URL.EscapedPath(),
+		RawQueryString:        r.URL.RawQuery,
+		Cookies:               cookies,
+		Headers:               headers,
+		QueryStringParameters: queryStringParameters,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:     "$default",
+			AccountID:    "local",
+			Stage:        "$default",
+			RequestID:    requestID(),
+			APIID:        "local",
+			DomainName:   r.Host,
+			DomainPrefix: strings.Split(r.Host, ".")[0],
+			Time:         now.Format("02/Jan/2006:15:04:05 -0700"),
+			TimeEpoch:    now.UnixNano() / int64(time.Millisecond),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	if len(body) > 0 {
+		if isTextBody(r.Header, body) {
+			event.Body = string(body)
+		} else {
+			event.Body = base64.StdEncoding.EncodeToString(body)
+			event.IsBase64Encoded = true
+		}
+	}
+
+	return event, nil
+}
+
+func writeHTTPResponse(w http.ResponseWriter, resp events.APIGatewayV2HTTPResponse) error {
+	for name, value := range resp.Headers {
+		w.Header().Set(name, value)
+	}
+
+	for name, values := range resp.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	for _, cookie := range resp.Cookies {
+		w.Header().Add("Set-Cookie", cookie)
+	}
+
+	body := []byte(resp.Body)
+	if resp.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			http.Error(w, "invalid base64 response body", http.StatusBadGateway)
+			return err
+		}
+		body = decoded
+	}
+
+	statusCode := resp.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	w.WriteHeader(statusCode)
+	_, err := w.Write(body)
+
+	return err
+}
+
+// isTextBody mirrors API Gateway, which only passes bodies through untouched
+// when they are text. Everything else is base64 encoded.
+func isTextBody(header http.Header, body []byte) bool {
+	if header.Get("Content-Encoding") != "" || !utf8.Valid(body) {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/xml" ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+func requestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHTTPAdapter(t *testing.T) {
+	var received events.APIGatewayV2HTTPRequest
+	router := NewRouter(Route{
+		Method: http.MethodPut,
+		Path:   "/{id}",
+		Handler: func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			received = event
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode:      http.StatusCreated,
+				Body:            base64.StdEncoding.EncodeToString([]byte("created")),
+				IsBase64Encoded: true,
+			}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/a%20b?tag=x&tag=y", bytes.NewReader([]byte{0xff, 0x00}))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	rec := httptest.NewRecorder()
+
+	NewHTTPAdapter(router.Handler).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" {
+		t.Errorf("Got response %d %q", rec.Code, rec.Body.String())
+	}
+
+	if received.PathParameters["id"] != "a b" {
+		t.Errorf("Got path parameters %v", received.PathParameters)
+	}
+
+	if received.QueryStringParameters["tag"] != "x,y" {
+		t.Errorf("Got query string parameters %v", received.QueryStringParameters)
+	}
+
+	body, _ := requestBody(received)
+	if !received.IsBase64Encoded || !bytes.Equal(body, []byte{0xff, 0x00}) {
+		t.Errorf("Got body %q, base64 %t", received.Body, received.IsBase64Encoded)
+	}
+
+	if received.Headers["content-type"] != "application/octet-stream" {
+		t.Errorf("Got headers %v", received.Headers)
+	}
+}
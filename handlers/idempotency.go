@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/auth"
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	DefaultIdempotencyTTL    = 24 * time.Hour
+	maxIdempotencyKeyLength  = 255
+
+	// idempotencyReservationTTL frees the key of a request that never
+	// finished, well after any function timeout.
+	idempotencyReservationTTL = time.Minute
+)
+
+// Idempotent stores the first response of every mutating request that carries
+// an Idempotency-Key header and replays it for retries with the same body.
+// Reusing a key for a different request is answered with 422, and retrying
+// while the first attempt is still running with 409. Keys are scoped to the
+// authenticated principal, so clients cannot see each other's responses.
+func Idempotent(s types.IdempotencyStore, ttl time.Duration) Middleware {
+	return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+		return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			key, ok := header(event, IdempotencyKeyHeader)
+			if !ok || !isMutatingMethod(event.RequestContext.HTTP.Method) {
+				return next(ctx, event)
+			}
+
+			if key == "" || len(key) > maxIdempotencyKeyLength {
+				return errResponse(http.StatusBadRequest, fmt.Sprintf("'%s' header must be between 1 and %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)), nil
+			}
+
+			// Keys are only unique per client, so they are scoped to the caller when known.
+			if principal, ok := auth.FromContext(ctx); ok && principal.Subject != "" {
+				key = principal.Subject + "#" + key
+			}
+
+			fingerprint := requestFingerprint(event)
+
+			record, err := s.Get(ctx, key)
+			if err != nil {
+				return errResponse(http.StatusInternalServerError, err.Error()), nil
+			}
+
+			if record == nil {
+				// The key is reserved before the request runs so that a retry
+				// racing the first attempt cannot run it a second time.
+				reserved, err := s.Reserve(ctx, types.IdempotencyRecord{
+					Key:         key,
+					Fingerprint: fingerprint,
+					Pending:     true,
+					ExpiresAt:   time.Now().Add(idempotencyReservationTTL).Unix(),
+				})
+				if err != nil {
+					return errResponse(http.StatusInternalServerError, err.Error()), nil
+				}
+
+				if !reserved {
+					record, err = s.Get(ctx, key)
+					if err != nil {
+						return errResponse(http.StatusInternalServerError, err.Error()), nil
+					}
+
+					// The other record expired in between, which only pending ones do this soon.
+					if record == nil {
+						record = &types.IdempotencyRecord{Fingerprint: fingerprint, Pending: true}
+					}
+				}
+			}
+
+			if record != nil {
+				if record.Fingerprint != fingerprint {
+					return errResponse(http.StatusUnprocessableEntity, fmt.Sprintf("'%s' was already used for a different request", IdempotencyKeyHeader)), nil
+				}
+
+				if record.Pending {
+					return errResponse(http.StatusConflict, fmt.Sprintf("a request with this '%s' is still in progress", IdempotencyKeyHeader)), nil
+				}
+
+				return replayResponse(*record), nil
+			}
+
+			resp, err := next(ctx, event)
+
+			// Server errors are not stored so that the client can retry them.
+			if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+				if err := s.Delete(ctx, key); err != nil {
+					log.Printf("cannot release idempotency key: %s", err)
+				}
+
+				return resp, err
+			}
+
+			err = s.Put(ctx, types.IdempotencyRecord{
+				Key:             key,
+				Fingerprint:     fingerprint,
+				StatusCode:      resp.StatusCode,
+				Headers:         resp.Headers,
+				Body:            resp.Body,
+				IsBase64Encoded: resp.IsBase64Encoded,
+				ExpiresAt:       time.Now().Add(ttl).Unix(),
+			})
+			if err != nil {
+				return errResponse(http.StatusInternalServerError, err.Error()), nil
+			}
+
+			return resp, nil
+		}
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func requestFingerprint(event events.APIGatewayV2HTTPRequest) string {
+	hash := sha256.New()
+	hash.Write([]byte(event.RequestContext.HTTP.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(event.RawPath))
+	hash.Write([]byte{0})
+	hash.Write([]byte(event.Body))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replayResponse(record types.IdempotencyRecord) events.APIGatewayV2HTTPResponse {
+	headers := make(map[string]string, len(record.Headers)+1)
+	for k, v := range record.Headers {
+		headers[k] = v
+	}
+	headers[IdempotentReplayedHeader] = "true"
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode:      record.StatusCode,
+		Headers:         headers,
+		Body:            record.Body,
+		IsBase64Encoded: record.IsBase64Encoded,
+	}
+}
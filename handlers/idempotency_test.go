@@ -0,0 +1,129 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws-samples/serverless-go-demo/auth"
+	"github.com/aws-samples/serverless-go-demo/store"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func putRequest(key string, body string) events.APIGatewayV2HTTPRequest {
+	return events.APIGatewayV2HTTPRequest{
+		RawPath:        "/1",
+		Headers:        map[string]string{"idempotency-key": key},
+		PathParameters: map[string]string{"id": "1"},
+		Body:           body,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodPut},
+		},
+	}
+}
+
+func TestIdempotentReplaysFirstResponse(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	handler := Idempotent(store.NewMemoryIdempotencyStore(), DefaultIdempotencyTTL)(
+		func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			calls++
+			return response(http.StatusCreated, calls), nil
+		})
+
+	first, _ := handler(ctx, putRequest("abc", `{"id":"1"}`))
+	second, _ := handler(ctx, putRequest("abc", `{"id":"1"}`))
+
+	if calls != 1 {
+		t.Errorf("Handler called %d times, expected 1", calls)
+	}
+
+	if second.StatusCode != first.StatusCode || second.Body != first.Body {
+		t.Errorf("Replayed response %v does not match %v", second, first)
+	}
+
+	if second.Headers[IdempotentReplayedHeader] != "true" {
+		t.Errorf("Replayed response is missing the '%s' header", IdempotentReplayedHeader)
+	}
+}
+
+func TestIdempotentRejectsReusedKey(t *testing.T) {
+	ctx := context.Background()
+	handler := Idempotent(store.NewMemoryIdempotencyStore(), DefaultIdempotencyTTL)(
+		func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			return response(http.StatusCreated, nil), nil
+		})
+
+	handler(ctx, putRequest("abc", `{"id":"1"}`))
+	resp, _ := handler(ctx, putRequest("abc", `{"id":"2"}`))
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Got status %d, expected %d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotentDoesNotStoreServerErrors(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	handler := Idempotent(store.NewMemoryIdempotencyStore(), DefaultIdempotencyTTL)(
+		func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			calls++
+			return errResponse(http.StatusInternalServerError, "boom"), nil
+		})
+
+	handler(ctx, putRequest("abc", `{"id":"1"}`))
+	handler(ctx, putRequest("abc", `{"id":"1"}`))
+
+	if calls != 2 {
+		t.Errorf("Handler called %d times, expected 2", calls)
+	}
+}
+
+func TestIdempotentRejectsRetriesInProgress(t *testing.T) {
+	ctx := context.Background()
+	var handler APIGatewayV2HandlerFunc
+	var retry events.APIGatewayV2HTTPResponse
+	calls := 0
+	handler = Idempotent(store.NewMemoryIdempotencyStore(), DefaultIdempotencyTTL)(
+		func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			calls++
+			if calls == 1 {
+				retry, _ = handler(ctx, putRequest("abc", `{"id":"1"}`))
+			}
+			return response(http.StatusCreated, nil), nil
+		})
+
+	first, _ := handler(ctx, putRequest("abc", `{"id":"1"}`))
+
+	if calls != 1 {
+		t.Errorf("Handler called %d times, expected 1", calls)
+	}
+
+	if retry.StatusCode != http.StatusConflict || first.StatusCode != http.StatusCreated {
+		t.Errorf("Got statuses %d and %d, expected %d and %d", first.StatusCode, retry.StatusCode, http.StatusCreated, http.StatusConflict)
+	}
+
+	if replayed, _ := handler(ctx, putRequest("abc", `{"id":"1"}`)); replayed.Headers[IdempotentReplayedHeader] != "true" {
+		t.Errorf("Got response %v, expected a replay once the first request finished", replayed)
+	}
+}
+
+func TestIdempotentScopesKeysByPrincipal(t *testing.T) {
+	calls := 0
+	handler := Idempotent(store.NewMemoryIdempotencyStore(), DefaultIdempotencyTTL)(
+		func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			calls++
+			return response(http.StatusCreated, nil), nil
+		})
+
+	handler(auth.NewContext(context.Background(), auth.Principal{Subject: "alice"}), putRequest("abc", `{"id":"1"}`))
+	resp, _ := handler(auth.NewContext(context.Background(), auth.Principal{Subject: "bob"}), putRequest("abc", `{"id":"2"}`))
+
+	if calls != 2 || resp.StatusCode != http.StatusCreated {
+		t.Errorf("Got status %d after %d calls, expected bob's request to run", resp.StatusCode, calls)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type APIGatewayV2HandlerFunc func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+
+type Middleware func(APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc
+
+// header looks up a request header ignoring its case, as API Gateway lowercases
+// header names on HTTP APIs but local tools usually don't.
+func header(event events.APIGatewayV2HTTPRequest, name string) (string, bool) {
+	if value, ok := event.Headers[name]; ok {
+		return value, true
+	}
+
+	for key, value := range event.Headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// withVary returns a copy of the response headers with name added to Vary.
+func withVary(headers map[string]string, name string) map[string]string {
+	copied := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		copied[key] = value
+	}
+
+	if vary, ok := copied["Vary"]; ok && vary != "" {
+		copied["Vary"] = vary + ", " + name
+	} else {
+		copied["Vary"] = name
+	}
+
+	return copied
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws-samples/serverless-go-demo/jsonschema"
+	"github.com/aws-samples/serverless-go-demo/openapi"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	OpenAPITitle   = "Serverless Go Demo products API"
+	OpenAPIVersion = "1.0.0"
+
+	bearerSecurityScheme = "bearerAuth"
+)
+
+// errorBody documents the payload built by errResponse.
+type errorBody struct {
+	Message string `json:"message"`
+}
+
+// NewOpenAPIDocument describes the routes as an OpenAPI 3 document, using the
+// same schemas that Validate checks requests against.
+func NewOpenAPIDocument(routes []Route) *openapi.Document {
+	doc := openapi.NewDocument(OpenAPITitle, OpenAPIVersion)
+	errorRef := doc.AddSchema("Error", jsonschema.Reflect(errorBody{}))
+	problemRef := doc.AddSchema("Problem", jsonschema.Reflect(problem{}))
+	doc.Components.SecuritySchemes[bearerSecurityScheme] = openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+	}
+
+	for _, route := range routes {
+		operation := openapi.Operation{
+			OperationID: route.Name,
+			Summary:     route.Summary,
+			Responses:   map[string]openapi.Response{},
+		}
+
+		for _, name := range pathParameters(route.Path) {
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &jsonschema.Schema{Type: "string"},
+			})
+		}
+
+		for _, query := range route.Query {
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				Name:        query.Name,
+				In:          "query",
+				Description: query.Description,
+				Required:    query.Required,
+				Schema:      &jsonschema.Schema{Type: "string"},
+			})
+		}
+
+		if route.RequestBody != nil {
+			operation.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content: map[string]openapi.MediaType{
+					"application/json": {Schema: addTypeSchema(doc, route.RequestBody)},
+				},
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		success := openapi.Response{Description: http.StatusText(status)}
+		if route.Response != nil {
+			success.Content = map[string]openapi.MediaType{
+				"application/json": {Schema: addTypeSchema(doc, route.Response)},
+			}
+		}
+		operation.Responses[strconv.Itoa(status)] = success
+
+		errorStatuses := []int{http.StatusInternalServerError}
+		if len(operation.Parameters) > 0 || route.RequestBody != nil {
+			errorStatuses = append(errorStatuses, http.StatusBadRequest)
+		}
+		if len(pathParameters(route.Path)) > 0 && route.Method == http.MethodGet {
+			errorStatuses = append(errorStatuses, http.StatusNotFound)
+		}
+
+		for _, errorStatus := range errorStatuses {
+			operation.Responses[strconv.Itoa(errorStatus)] = openapi.Response{
+				Description: http.StatusText(errorStatus),
+				Content: map[string]openapi.MediaType{
+					"application/json": {Schema: errorRef},
+				},
+			}
+		}
+
+		if len(route.Scopes) > 0 {
+			operation.Security = []openapi.SecurityRequirement{{bearerSecurityScheme: route.Scopes}}
+
+			for _, problemStatus := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+				operation.Responses[strconv.Itoa(problemStatus)] = openapi.Response{
+					Description: http.StatusText(problemStatus),
+					Content: map[string]openapi.MediaType{
+						"application/problem+json": {Schema: problemRef},
+					},
+				}
+			}
+		}
+
+		doc.AddOperation(strings.ToLower(route.Method), route.Path, operation)
+	}
+
+	return doc
+}
+
+// OpenAPIRoute serves the OpenAPI document of the given routes at GET /openapi.json.
+func OpenAPIRoute(routes []Route) Route {
+	doc := NewOpenAPIDocument(routes)
+
+	return Route{
+		Method: http.MethodGet,
+		Path:   "/openapi.json",
+		Handler: func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			return response(http.StatusOK, doc), nil
+		},
+		Name:    "getOpenAPIDocument",
+		Summary: "Get this OpenAPI document",
+		Status:  http.StatusOK,
+	}
+}
+
+func addTypeSchema(doc *openapi.Document, v interface{}) openapi.SchemaRef {
+	return doc.AddSchema(reflect.TypeOf(v).Name(), jsonschema.Reflect(v))
+}
+
+func pathParameters(template string) []string {
+	names := []string{}
+	for _, segment := range splitPath(template) {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.TrimSuffix(strings.Trim(segment, "{}"), "+"))
+		}
+	}
+
+	return names
+}
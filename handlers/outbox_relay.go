@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type OutboxRelayHandler struct {
+	relay *domain.OutboxRelay
+}
+
+func NewOutboxRelayHandler(r *domain.OutboxRelay) *OutboxRelayHandler {
+	return &OutboxRelayHandler{
+		relay: r,
+	}
+}
+
+// ScheduledHandler drains the outbox on a schedule. Changes that could not be
+// published stay in the outbox, so an error only means the next run has more
+// to do.
+func (o *OutboxRelayHandler) ScheduledHandler(ctx context.Context, event events.CloudWatchEvent) error {
+	relayed, err := o.relay.Relay(ctx)
+	log.Printf("relayed %d changes from the outbox", relayed)
+
+	return err
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/auth"
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const defaultRateLimitKey = "default"
+
+// RateLimitConfig holds the limit applied to every client of a route, keyed by
+// route key such as "GET /". Routes without a limit use Default.
+type RateLimitConfig struct {
+	Default types.RateLimit
+	Routes  map[string]types.RateLimit
+}
+
+// DefaultRateLimitConfig keeps the list route, which scans the table, well
+// below the limit of the single item routes.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Default: types.RateLimit{Requests: 50, Period: time.Second, Burst: 100},
+		Routes: map[string]types.RateLimit{
+			"GET /": {Requests: 5, Period: time.Second, Burst: 10},
+		},
+	}
+}
+
+// ParseRateLimitConfig reads a JSON object mapping route keys, or "default",
+// to limits written as "requests/period[:burst]", e.g.
+// {"default": "50/1s:100", "GET /": "5/1s"}. Routes missing from it keep the
+// limits of DefaultRateLimitConfig.
+func ParseRateLimitConfig(s string) (RateLimitConfig, error) {
+	config := DefaultRateLimitConfig()
+	if strings.TrimSpace(s) == "" {
+		return config, nil
+	}
+
+	limits := map[string]string{}
+	if err := json.Unmarshal([]byte(s), &limits); err != nil {
+		return RateLimitConfig{}, fmt.Errorf("invalid rate limits: %w", err)
+	}
+
+	for key, value := range limits {
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			return RateLimitConfig{}, fmt.Errorf("invalid rate limit for '%s': %w", key, err)
+		}
+
+		if key == defaultRateLimitKey {
+			config.Default = limit
+		} else {
+			config.Routes[key] = limit
+		}
+	}
+
+	return config, nil
+}
+
+func parseRateLimit(s string) (types.RateLimit, error) {
+	rate := strings.SplitN(strings.TrimSpace(s), ":", 2)
+
+	parts := strings.SplitN(rate[0], "/", 2)
+	if len(parts) != 2 {
+		return types.RateLimit{}, fmt.Errorf("expected requests/period, got '%s'", s)
+	}
+	requests, period := parts[0], parts[1]
+
+	limit := types.RateLimit{}
+	var err error
+	if limit.Requests, err = strconv.Atoi(requests); err != nil || limit.Requests < 0 {
+		return types.RateLimit{}, fmt.Errorf("invalid number of requests '%s'", requests)
+	}
+	if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 {
+		return types.RateLimit{}, fmt.Errorf("invalid period '%s'", period)
+	}
+	if len(rate) == 2 {
+		if limit.Burst, err = strconv.Atoi(rate[1]); err != nil || limit.Burst < 0 {
+			return types.RateLimit{}, fmt.Errorf("invalid burst '%s'", rate[1])
+		}
+	}
+
+	return limit, nil
+}
+
+// RateLimit answers 429 to clients going over the limit of the route they
+// call. Clients are told apart by their authenticated principal, so it must be
+// applied inside Authorize, falling back to their source IP. Every response of
+// a limited route carries RateLimit-* headers. Requests are let through when
+// the bucket store fails, so that it can't take the API down.
+func RateLimit(limiter *domain.RateLimiter, config RateLimitConfig, routes []Route) Middleware {
+	router := NewRouter(routes...)
+
+	return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+		return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			routeKey := event.RouteKey
+			if route, _, _ := router.match(event); route != nil {
+				routeKey = route.Key()
+			}
+
+			limit, ok := config.Routes[routeKey]
+			if !ok {
+				limit = config.Default
+			}
+			if limit.Requests <= 0 {
+				return next(ctx, event)
+			}
+
+			decision, err := limiter.Allow(ctx, rateLimitClient(ctx, event)+"#"+routeKey, limit)
+			if err != nil {
+				log.Printf("rate limiter unavailable, letting request through: %v", err)
+				return next(ctx, event)
+			}
+
+			if !decision.Allowed {
+				resp := problemResponse(http.StatusTooManyRequests, fmt.Sprintf("rate limit of %d requests per %s exceeded", limit.Requests, limit.Period))
+				setRateLimitHeaders(resp.Headers, decision)
+				resp.Headers["Retry-After"] = strconv.Itoa(ceilSeconds(decision.RetryAfter))
+				return resp, nil
+			}
+
+			resp, err := next(ctx, event)
+			if err != nil {
+				return resp, err
+			}
+
+			if resp.Headers == nil {
+				resp.Headers = map[string]string{}
+			}
+			setRateLimitHeaders(resp.Headers, decision)
+
+			return resp, nil
+		}
+	}
+}
+
+// rateLimitClient identifies the caller, preferring the principal stored by
+// Authorize, then the one of an API Gateway authorizer and then the source IP.
+func rateLimitClient(ctx context.Context, event events.APIGatewayV2HTTPRequest) string {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		principal, _ = principalFromRequest(event, nil)
+	}
+
+	if principal.Subject != "" {
+		return principal.Method + ":" + principal.Subject
+	}
+
+	return "ip:" + event.RequestContext.HTTP.SourceIP
+}
+
+func setRateLimitHeaders(headers map[string]string, decision domain.RateLimitDecision) {
+	headers["RateLimit-Limit"] = strconv.Itoa(decision.Limit)
+	headers["RateLimit-Remaining"] = strconv.Itoa(decision.Remaining)
+	headers["RateLimit-Reset"] = strconv.Itoa(ceilSeconds(decision.Reset))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,73 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/store"
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestParseRateLimitConfig(t *testing.T) {
+	config, err := ParseRateLimitConfig(`{"default": "10/1m", "GET /{id}": "2/1s:4"}`)
+	if err != nil {
+		t.Fatalf("ParseRateLimitConfig returned an error: %s", err)
+	}
+
+	if config.Default != (types.RateLimit{Requests: 10, Period: time.Minute}) {
+		t.Errorf("Got default limit %+v", config.Default)
+	}
+	if config.Routes["GET /{id}"] != (types.RateLimit{Requests: 2, Period: time.Second, Burst: 4}) {
+		t.Errorf("Got route limit %+v", config.Routes["GET /{id}"])
+	}
+	if _, ok := config.Routes["GET /"]; !ok {
+		t.Errorf("Default route limits were dropped")
+	}
+
+	if _, err := ParseRateLimitConfig(`{"default": "10"}`); err == nil {
+		t.Errorf("Expected an error for a limit without period")
+	}
+}
+
+func TestRateLimitAnswersTooManyRequests(t *testing.T) {
+	okHandler := func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusOK}, nil
+	}
+	routes := []Route{{Method: http.MethodGet, Path: "/", Handler: okHandler}}
+	config := RateLimitConfig{Routes: map[string]types.RateLimit{
+		"GET /": {Requests: 1, Period: time.Minute},
+	}}
+	fn := RateLimit(domain.NewRateLimiter(store.NewMemoryRateLimitStore()), config, routes)(okHandler)
+
+	request := func(ip string) events.APIGatewayV2HTTPResponse {
+		resp, _ := fn(context.Background(), events.APIGatewayV2HTTPRequest{
+			RawPath: "/",
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet, SourceIP: ip},
+			},
+		})
+		return resp
+	}
+
+	resp := request("192.0.2.1")
+	if resp.StatusCode != http.StatusOK || resp.Headers["RateLimit-Remaining"] != "0" {
+		t.Fatalf("Got status %d and headers %v for the first request", resp.StatusCode, resp.Headers)
+	}
+
+	resp = request("192.0.2.1")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Headers["Retry-After"] != "60" {
+		t.Errorf("Got status %d and headers %v over the limit", resp.StatusCode, resp.Headers)
+	}
+
+	if resp := request("192.0.2.2"); resp.StatusCode != http.StatusOK {
+		t.Errorf("Got status %d for another client", resp.StatusCode)
+	}
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type Route struct {
+	Method  string
+	Path    string
+	Handler APIGatewayV2HandlerFunc
+
+	// The fields below describe the route in the OpenAPI document and are used
+	// to validate requests before they reach the handler.
+	Name        string
+	Summary     string
+	Query       []QueryParameter
+	RequestBody interface{}
+	Response    interface{}
+	Status      int
+	Scopes      []string
+}
+
+type QueryParameter struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Key returns the route in the same "METHOD /path" form API Gateway uses for
+// RouteKey.
+func (r Route) Key() string {
+	return r.Method + " " + r.Path
+}
+
+// Router dispatches API Gateway HTTP API requests to the handler registered for
+// their route, so that a single Lambda function can serve the whole API.
+type Router struct {
+	routes []Route
+	byKey  map[string]Route
+}
+
+func NewRouter(routes ...Route) *Router {
+	router := &Router{
+		byKey: make(map[string]Route, len(routes)),
+	}
+
+	for _, route := range routes {
+		router.routes = append(router.routes, route)
+		router.byKey[route.Key()] = route
+	}
+
+	return router
+}
+
+// Routes returns the product API routes served by the handler.
+func (l *APIGatewayV2Handler) Routes() []Route {
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/",
+			Handler: l.AllHandler,
+			Name:    "listProducts",
+			Summary: "List products, one page at a time",
+			Query: []QueryParameter{
+				{Name: "next", Description: "Pagination token returned as 'next' by the previous page"},
+			},
+			Response: types.ProductRange{},
+			Status:   http.StatusOK,
+			Scopes:   []string{ScopeProductsRead},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/{id}",
+			Handler:  l.GetHandler,
+			Name:     "getProduct",
+			Summary:  "Get a product",
+			Response: types.Product{},
+			Status:   http.StatusOK,
+			Scopes:   []string{ScopeProductsRead},
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/{id}",
+			Handler:     l.PutHandler,
+			Name:        "putProduct",
+			Summary:     "Create or replace a product",
+			RequestBody: types.Product{},
+			Response:    types.Product{},
+			Status:      http.StatusCreated,
+			Scopes:      []string{ScopeProductsWrite},
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/{id}",
+			Handler: l.DeleteHandler,
+			Name:    "deleteProduct",
+			Summary: "Delete a product",
+			Status:  http.StatusOK,
+			Scopes:  []string{ScopeProductsWrite},
+		},
+	}
+}
+
+func (r *Router) Routes() []Route {
+	return r.routes
+}
+
+func (r *Router) Handler(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	matched, matchedParams, allowed := r.match(event)
+	if matched == nil {
+		if len(allowed) > 0 {
+			resp := errResponse(http.StatusMethodNotAllowed, "method not allowed")
+			resp.Headers["Allow"] = strings.Join(allowed, ", ")
+			return resp, nil
+		}
+
+		return errResponse(http.StatusNotFound, "route not found"), nil
+	}
+
+	event.PathParameters = mergeParameters(event.PathParameters, matchedParams)
+	event.RouteKey = matched.Key()
+
+	return matched.Handler(ctx, event)
+}
+
+// match finds the route for a request by its method and path. When the path
+// matches but the method doesn't, it returns the allowed methods instead.
+func (r *Router) match(event events.APIGatewayV2HTTPRequest) (*Route, map[string]string, []string) {
+	if route, ok := r.byKey[event.RouteKey]; ok {
+		return &route, nil, nil
+	}
+
+	method := event.RequestContext.HTTP.Method
+	path := requestPath(event)
+
+	var matched *Route
+	var matchedParams map[string]string
+	allowed := []string{}
+
+	for i, route := range r.routes {
+		params, ok := matchPath(route.Path, path)
+		if !ok {
+			continue
+		}
+
+		if route.Method != method {
+			allowed = append(allowed, route.Method)
+			continue
+		}
+
+		if matched == nil || moreSpecific(route.Path, matched.Path) {
+			matched = &r.routes[i]
+			matchedParams = params
+		}
+	}
+
+	if matched == nil {
+		return nil, nil, uniqueSorted(allowed)
+	}
+
+	return matched, matchedParams, nil
+}
+
+func mergeParameters(existing map[string]string, matched map[string]string) map[string]string {
+	if len(matched) == 0 {
+		return existing
+	}
+
+	parameters := make(map[string]string, len(existing)+len(matched))
+	for k, v := range existing {
+		parameters[k] = v
+	}
+	for k, v := range matched {
+		parameters[k] = v
+	}
+
+	return parameters
+}
+
+// requestPath returns the request path without the stage prefix API Gateway adds
+// for named stages.
+func requestPath(event events.APIGatewayV2HTTPRequest) string {
+	path := event.RawPath
+	if path == "" {
+		path = event.RequestContext.HTTP.Path
+	}
+
+	stage := event.RequestContext.Stage
+	if stage != "" && stage != "$default" {
+		path = strings.TrimPrefix(path, "/"+stage)
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	return path
+}
+
+// matchPath matches a request path against a path template such as "/{id}",
+// returning the values of its parameters. A trailing "{name+}" parameter
+// matches the rest of the path.
+func matchPath(template string, path string) (map[string]string, bool) {
+	templateSegments := splitPath(template)
+	pathSegments := splitPath(path)
+	params := map[string]string{}
+
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "+}") {
+			if i >= len(pathSegments) {
+				return nil, false
+			}
+
+			value, err := url.PathUnescape(strings.Join(pathSegments[i:], "/"))
+			if err != nil {
+				return nil, false
+			}
+
+			params[segment[1:len(segment)-2]] = value
+			return params, true
+		}
+
+		if i >= len(pathSegments) {
+			return nil, false
+		}
+
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			value, err := url.PathUnescape(pathSegments[i])
+			if err != nil {
+				return nil, false
+			}
+
+			params[segment[1:len(segment)-1]] = value
+			continue
+		}
+
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+
+	if len(templateSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// moreSpecific reports whether template a should win over template b when both
+// match, preferring literal segments over parameters like API Gateway does.
+func moreSpecific(a string, b string) bool {
+	return literalSegments(a) > literalSegments(b)
+}
+
+func literalSegments(template string) int {
+	count := 0
+	for _, segment := range splitPath(template) {
+		if !strings.HasPrefix(segment, "{") {
+			count++
+		}
+	}
+
+	return count
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+
+	return strings.Split(path, "/")
+}
+
+func uniqueSorted(values []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	sort.Strings(unique)
+
+	return unique
+}
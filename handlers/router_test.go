@@ -0,0 +1,82 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func routedRequest(routeKey string, method string, path string) events.APIGatewayV2HTTPRequest {
+	return events.APIGatewayV2HTTPRequest{
+		RouteKey: routeKey,
+		RawPath:  path,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			Stage: "$default",
+			HTTP:  events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: method, Path: path},
+		},
+	}
+}
+
+func echoRoute(method string, path string) Route {
+	return Route{
+		Method: method,
+		Path:   path,
+		Handler: func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			return response(http.StatusOK, map[string]interface{}{
+				"route":  event.RouteKey,
+				"params": event.PathParameters,
+			}), nil
+		},
+	}
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	router := NewRouter(
+		echoRoute(http.MethodGet, "/"),
+		echoRoute(http.MethodGet, "/search"),
+		echoRoute(http.MethodGet, "/{id}"),
+		echoRoute(http.MethodPut, "/{id}"),
+	)
+
+	tests := []struct {
+		name   string
+		event  events.APIGatewayV2HTTPRequest
+		status int
+		body   string
+	}{
+		{"by route key", routedRequest("GET /{id}", http.MethodGet, "/1"), http.StatusOK, `{"params":null,"route":"GET /{id}"}`},
+		{"by path", routedRequest("$default", http.MethodGet, "/1"), http.StatusOK, `{"params":{"id":"1"},"route":"GET /{id}"}`},
+		{"root", routedRequest("$default", http.MethodGet, "/"), http.StatusOK, `{"params":null,"route":"GET /"}`},
+		{"literal over parameter", routedRequest("$default", http.MethodGet, "/search"), http.StatusOK, `{"params":null,"route":"GET /search"}`},
+		{"not found", routedRequest("$default", http.MethodGet, "/1/2"), http.StatusNotFound, ""},
+		{"method not allowed", routedRequest("$default", http.MethodDelete, "/1"), http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := router.Handler(ctx, test.event)
+			if err != nil {
+				t.Fatalf("Got unexpected error: %s", err)
+			}
+
+			if resp.StatusCode != test.status {
+				t.Errorf("Got status %d, expected %d", resp.StatusCode, test.status)
+			}
+
+			if test.body != "" && resp.Body != test.body {
+				t.Errorf("Got body %s, expected %s", resp.Body, test.body)
+			}
+		})
+	}
+
+	resp, _ := router.Handler(ctx, routedRequest("$default", http.MethodDelete, "/1"))
+	if resp.Headers["Allow"] != "GET, PUT" {
+		t.Errorf("Got Allow header %q", resp.Headers["Allow"])
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type SearchHandler struct {
+	search *domain.Search
+}
+
+func NewSearchHandler(s *domain.Search) *SearchHandler {
+	return &SearchHandler{
+		search: s,
+	}
+}
+
+// Routes returns the search route, which takes precedence over "/{id}" for
+// the path "/search".
+func (h *SearchHandler) Routes() []Route {
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/search",
+			Handler: h.SearchHandler,
+			Name:    "searchProducts",
+			Summary: "Search products by name, tags and price bucket, best matches first",
+			Query: []QueryParameter{
+				{Name: "q", Description: "Words to look for, and price buckets such as 'price:10-50'", Required: true},
+				{Name: "next", Description: "Pagination token returned as 'next' by the previous page"},
+			},
+			Response: types.ProductRange{},
+			Status:   http.StatusOK,
+			Scopes:   []string{ScopeProductsRead},
+		},
+	}
+}
+
+func (h *SearchHandler) SearchHandler(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	query := event.QueryStringParameters["q"]
+	next := event.QueryStringParameters["next"]
+
+	productRange, err := h.search.Search(ctx, query, &next)
+	if err != nil {
+		if errors.Is(err, domain.ErrEmptyQuery) || errors.Is(err, domain.ErrTooManyTerms) || errors.Is(err, domain.ErrInvalidSearchNext) {
+			return errResponse(http.StatusBadRequest, err.Error()), nil
+		}
+
+		return errResponse(http.StatusInternalServerError, err.Error()), nil
+	}
+
+	return response(http.StatusOK, productRange), nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type SearchProjectorHandler struct {
+	projector *domain.SearchProjector
+}
+
+func NewSearchProjectorHandler(p *domain.SearchProjector) *SearchProjectorHandler {
+	return &SearchProjectorHandler{
+		projector: p,
+	}
+}
+
+// StreamHandler projects a batch of table stream records on the search
+// index. Records that can't be read are logged and skipped, as retrying them
+// wouldn't help. When the index can't be written, every record is reported
+// so that the whole batch is projected again.
+func (s *SearchProjectorHandler) StreamHandler(ctx context.Context, event events.DynamoDBEvent) (StreamsEventResponse, error) {
+	changes := make([]types.ProductChange, 0, len(event.Records))
+
+	for _, record := range event.Records {
+		change, err := changeFromDynamoDBRecord(record)
+		if err != nil {
+			log.Printf("skipping unreadable record %s: %v", record.EventID, err)
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	if err := s.projector.Project(ctx, changes); err != nil {
+		log.Printf("failed to project %d changes, retrying the whole batch: %v", len(changes), err)
+
+		itemFailures := make([]BatchItemFailure, len(event.Records))
+		for i, record := range event.Records {
+			itemFailures[i] = BatchItemFailure{ItemIdentifier: record.Change.SequenceNumber}
+		}
+
+		return StreamsEventResponse{BatchItemFailures: itemFailures}, nil
+	}
+
+	return StreamsEventResponse{BatchItemFailures: []BatchItemFailure{}}, nil
+}
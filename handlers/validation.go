@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws-samples/serverless-go-demo/jsonschema"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Validate checks requests against the route they are sent to, using the same
+// schemas published in the OpenAPI document, so that invalid requests never
+// reach the domain. Requests that don't match any route are passed through.
+func Validate(routes []Route) Middleware {
+	router := NewRouter(routes...)
+	bodySchemas := map[string]*jsonschema.Schema{}
+
+	for _, route := range routes {
+		if route.RequestBody != nil {
+			bodySchemas[route.Key()] = jsonschema.Reflect(route.RequestBody)
+		}
+	}
+
+	return func(next APIGatewayV2HandlerFunc) APIGatewayV2HandlerFunc {
+		return func(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+			route, params, _ := router.match(event)
+			if route == nil {
+				return next(ctx, event)
+			}
+
+			pathParams := mergeParameters(event.PathParameters, params)
+			for _, name := range pathParameters(route.Path) {
+				if pathParams[name] == "" {
+					return errResponse(http.StatusBadRequest, fmt.Sprintf("missing '%s' parameter in path", name)), nil
+				}
+			}
+
+			for _, query := range route.Query {
+				if query.Required && event.QueryStringParameters[query.Name] == "" {
+					return errResponse(http.StatusBadRequest, fmt.Sprintf("missing '%s' query string parameter", query.Name)), nil
+				}
+			}
+
+			schema, ok := bodySchemas[route.Key()]
+			if !ok {
+				return next(ctx, event)
+			}
+
+			body, err := requestBody(event)
+			if err != nil {
+				return errResponse(http.StatusBadRequest, err.Error()), nil
+			}
+
+			if strings.TrimSpace(string(body)) == "" {
+				return errResponse(http.StatusBadRequest, "empty request body"), nil
+			}
+
+			// Syntax errors are left to the domain, which reports them with the
+			// same error it uses when decoding.
+			if !json.Valid(body) {
+				return next(ctx, event)
+			}
+
+			if errs := schema.ValidateJSON(body); len(errs) > 0 {
+				messages := make([]string, len(errs))
+				for i, err := range errs {
+					messages[i] = err.Error()
+				}
+
+				return errResponse(http.StatusBadRequest, "invalid request body: "+strings.Join(messages, "; ")), nil
+			}
+
+			return next(ctx, event)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Change is a difference between two versions of a schema.
+type Change struct {
+	Path    string
+	Message string
+	// Breaking is true when documents valid under the new version can be
+	// invalid under the old one, so that consumers written against the old
+	// version would reject them or miss data they rely on.
+	Breaking bool
+}
+
+func (c Change) String() string {
+	kind := "compatible"
+	if c.Breaking {
+		kind = "breaking"
+	}
+
+	if c.Path == "" {
+		return fmt.Sprintf("%s: %s", kind, c.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", kind, c.Path, c.Message)
+}
+
+// Compare lists the changes from the old version of a schema to the new one.
+// Descriptions and other annotations are ignored.
+func Compare(old *Schema, new *Schema) []Change {
+	return compare("", old, new)
+}
+
+// Breaking returns the breaking changes among changes.
+func Breaking(changes []Change) []Change {
+	breaking := []Change{}
+	for _, change := range changes {
+		if change.Breaking {
+			breaking = append(breaking, change)
+		}
+	}
+
+	return breaking
+}
+
+func compare(path string, old *Schema, new *Schema) []Change {
+	changes := []Change{}
+
+	if old.Type != new.Type {
+		// A schema without a type accepts anything.
+		breaking := old.Type != ""
+		return append(changes, Change{path, fmt.Sprintf("type changed from '%s' to '%s'", old.Type, new.Type), breaking})
+	}
+
+	// Formats of numbers only tell their precision.
+	if old.Format != new.Format {
+		breaking := old.Format != "" && old.Type == "string"
+		changes = append(changes, Change{path, fmt.Sprintf("format changed from '%s' to '%s'", old.Format, new.Format), breaking})
+	}
+
+	if len(old.Enum) > 0 {
+		if len(new.Enum) == 0 {
+			changes = append(changes, Change{path, "no longer restricted to a set of values", true})
+		}
+		for _, value := range new.Enum {
+			if !containsValue(old.Enum, value) {
+				changes = append(changes, Change{path, fmt.Sprintf("value %v is now allowed", value), true})
+			}
+		}
+	} else if len(new.Enum) > 0 {
+		changes = append(changes, Change{path, "now restricted to a set of values", false})
+	}
+
+	if old.MinLength != nil && (new.MinLength == nil || *new.MinLength < *old.MinLength) {
+		changes = append(changes, Change{path, "minimum length lowered", true})
+	}
+
+	if old.Minimum != nil && (new.Minimum == nil || *new.Minimum < *old.Minimum) {
+		changes = append(changes, Change{path, "minimum lowered", true})
+	}
+
+	if old.Items != nil && new.Items != nil {
+		changes = append(changes, compare(path+"[]", old.Items, new.Items)...)
+	}
+
+	if old.Type == "object" {
+		changes = append(changes, compareObjects(path, old, new)...)
+	}
+
+	return changes
+}
+
+func compareObjects(path string, old *Schema, new *Schema) []Change {
+	changes := []Change{}
+
+	for _, name := range old.Required {
+		if !containsString(new.Required, name) {
+			changes = append(changes, Change{joinPath(path, name), "is no longer required", true})
+		}
+	}
+
+	for _, name := range new.Required {
+		if !containsString(old.Required, name) {
+			changes = append(changes, Change{joinPath(path, name), "is now required", false})
+		}
+	}
+
+	for _, name := range sortedNames(old.Properties) {
+		if _, ok := new.Properties[name]; !ok {
+			changes = append(changes, Change{joinPath(path, name), "was removed", false})
+		}
+	}
+
+	for _, name := range sortedNames(new.Properties) {
+		property := new.Properties[name]
+
+		oldProperty, ok := old.Properties[name]
+		switch {
+		case ok:
+			changes = append(changes, compare(joinPath(path, name), oldProperty, property)...)
+		case !old.AllowAdditionalProperties:
+			changes = append(changes, Change{joinPath(path, name), "was added but the old version allows no other properties", true})
+		case old.AdditionalProperties != nil:
+			changes = append(changes, Change{joinPath(path, name), "was added", false})
+			changes = append(changes, compare(joinPath(path, name), old.AdditionalProperties, property)...)
+		default:
+			changes = append(changes, Change{joinPath(path, name), "was added", false})
+		}
+	}
+
+	if new.AllowAdditionalProperties && !old.AllowAdditionalProperties {
+		changes = append(changes, Change{path, "now allows other properties", true})
+	}
+
+	if old.AdditionalProperties != nil {
+		if new.AdditionalProperties != nil {
+			changes = append(changes, compare(path+".*", old.AdditionalProperties, new.AdditionalProperties)...)
+		} else if new.AllowAdditionalProperties {
+			changes = append(changes, Change{path, "other properties are no longer constrained", true})
+		}
+	}
+
+	return changes
+}
+
+func sortedNames(properties map[string]*Schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+//go:build unit
+// +build unit
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	old := `{"type": "object", "properties": {
+		"id": {"type": "string"},
+		"price": {"type": "number"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"color": {"type": "string", "enum": ["red", "blue"]}
+	}, "required": ["id", "price"]}`
+
+	tests := []struct {
+		name     string
+		new      string
+		breaking bool
+	}{
+		{"unchanged", old, false},
+		{"optional property added", `{"type": "object", "properties": {
+			"id": {"type": "string"}, "price": {"type": "number"}, "stock": {"type": "integer"}
+		}, "required": ["id", "price"]}`, false},
+		{"required property dropped", `{"type": "object", "properties": {
+			"id": {"type": "string"}, "price": {"type": "number"}
+		}, "required": ["id"]}`, true},
+		{"type changed", `{"type": "object", "properties": {
+			"id": {"type": "string"}, "price": {"type": "string"}
+		}, "required": ["id", "price"]}`, true},
+		{"item type changed", `{"type": "object", "properties": {
+			"id": {"type": "string"}, "price": {"type": "number"}, "tags": {"type": "array", "items": {"type": "integer"}}
+		}, "required": ["id", "price"]}`, true},
+		{"enum widened", `{"type": "object", "properties": {
+			"id": {"type": "string"}, "price": {"type": "number"}, "color": {"type": "string", "enum": ["red", "blue", "green"]}
+		}, "required": ["id", "price"]}`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oldSchema, newSchema := &Schema{}, &Schema{}
+			json.Unmarshal([]byte(old), oldSchema)
+			json.Unmarshal([]byte(test.new), newSchema)
+
+			breaking := Breaking(Compare(oldSchema, newSchema))
+			if (len(breaking) > 0) != test.breaking {
+				t.Errorf("Got breaking changes %v, expected breaking: %v", breaking, test.breaking)
+			}
+		})
+	}
+}
+
+func TestCompareClosedObjects(t *testing.T) {
+	old := Reflect(struct {
+		Id string `json:"id"`
+	}{})
+	new := Reflect(struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}{})
+
+	if breaking := Breaking(Compare(old, new)); len(breaking) != 1 || breaking[0].Path != "name" {
+		t.Errorf("Got breaking changes %v, expected name to be added to a closed object", breaking)
+	}
+}
@@ -0,0 +1,104 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Reflect builds the schema of the JSON encoding of v from its Go type. Struct
+// fields are named after their json tag and are required unless the tag has
+// omitempty. A "description" struct tag is copied into the schema and a
+// `readOnly:"true"` tag marks fields set by the server.
+func Reflect(v interface{}) *Schema {
+	return reflectType(reflect.TypeOf(v))
+}
+
+func reflectType(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		return reflectType(t.Elem())
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: reflectType(t.Elem())}
+	case reflect.Map:
+		return &Schema{
+			Type:                      "object",
+			AllowAdditionalProperties: true,
+			AdditionalProperties:      reflectType(t.Elem()),
+		}
+	case reflect.Struct:
+		return reflectStruct(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func reflectStruct(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, options := parseTag(field.Tag.Get("json"))
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := reflectType(field.Type)
+			for propertyName, property := range embedded.Properties {
+				schema.Properties[propertyName] = property
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := reflectType(field.Type)
+		property.Description = field.Tag.Get("description")
+		property.ReadOnly = field.Tag.Get("readOnly") == "true"
+		schema.Properties[name] = property
+
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+func parseTag(tag string) (string, string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+
+	return tag, ""
+}
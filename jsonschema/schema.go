@@ -0,0 +1,78 @@
+package jsonschema
+
+import (
+	"encoding/json"
+)
+
+// Schema is the subset of JSON Schema (and of the OpenAPI 3 schema object) used
+// to describe and validate the API payloads.
+type Schema struct {
+	SchemaURI   string             `json:"$schema,omitempty"`
+	Id          string             `json:"$id,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	ReadOnly    bool               `json:"readOnly,omitempty"`
+
+	// AdditionalProperties describes the values of properties not listed in
+	// Properties. It is only used when AllowAdditionalProperties is true.
+	AdditionalProperties      *Schema `json:"-"`
+	AllowAdditionalProperties bool    `json:"-"`
+}
+
+type schemaAlias Schema
+
+type schemaJSON struct {
+	*schemaAlias
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+func (s Schema) MarshalJSON() ([]byte, error) {
+	alias := schemaAlias(s)
+	out := schemaJSON{schemaAlias: &alias}
+
+	if s.Type == "object" {
+		if !s.AllowAdditionalProperties {
+			out.AdditionalProperties = false
+		} else if s.AdditionalProperties != nil {
+			out.AdditionalProperties = s.AdditionalProperties
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	in := struct {
+		*schemaAlias
+		AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+	}{schemaAlias: (*schemaAlias)(s)}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	// Like JSON Schema, objects accept any additional property unless told otherwise.
+	s.AllowAdditionalProperties = s.Type == "object"
+	s.AdditionalProperties = nil
+
+	switch string(in.AdditionalProperties) {
+	case "", "true":
+	case "false":
+		s.AllowAdditionalProperties = false
+	default:
+		s.AdditionalProperties = &Schema{}
+		if err := json.Unmarshal(in.AdditionalProperties, s.AdditionalProperties); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
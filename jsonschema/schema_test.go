@@ -0,0 +1,71 @@
+//go:build unit
+// +build unit
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type testItem struct {
+	Id    string            `json:"id"`
+	Price float64           `json:"price"`
+	Tags  []string          `json:"tags,omitempty"`
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+func TestValidate(t *testing.T) {
+	schema := Reflect(testItem{})
+
+	tests := []struct {
+		name string
+		body string
+		errs []string
+	}{
+		{"valid", `{"id":"1","price":2.5,"tags":["a"],"extra":{"k":"v"}}`, []string{}},
+		{"missing required", `{"id":"1"}`, []string{"price: is required"}},
+		{"wrong types", `{"id":1,"price":"2","tags":[1],"extra":{"k":true}}`, []string{
+			"extra.k: expected string but got boolean",
+			"id: expected string but got integer",
+			"price: expected number but got string",
+			"tags[0]: expected string but got integer",
+		}},
+		{"unknown property", `{"id":"1","price":1,"color":"red"}`, []string{"color: is not a known property"}},
+		{"not an object", `[]`, []string{"expected object but got array"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := schema.ValidateJSON([]byte(test.body))
+
+			messages := []string{}
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+
+			if !reflect.DeepEqual(messages, test.errs) {
+				t.Errorf("Got %v, expected %v", messages, test.errs)
+			}
+		})
+	}
+}
+
+func TestSchemaJSONRoundTrip(t *testing.T) {
+	schema := Reflect(testItem{})
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Got unexpected error: %s", err)
+	}
+
+	decoded := &Schema{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Got unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(schema, decoded) {
+		t.Errorf("Decoded schema %s does not match the original", data)
+	}
+}
@@ -0,0 +1,178 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidateJSON decodes data and validates it against the schema.
+func (s *Schema) ValidateJSON(data []byte) []ValidationError {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []ValidationError{{Message: fmt.Sprintf("invalid JSON: %s", err)}}
+	}
+
+	return s.Validate(value)
+}
+
+// Validate checks a value decoded by encoding/json into an interface{} against
+// the schema, returning every violation found.
+func (s *Schema) Validate(value interface{}) []ValidationError {
+	return s.validate("", value)
+}
+
+func (s *Schema) validate(path string, value interface{}) []ValidationError {
+	errs := []ValidationError{}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		errs = append(errs, ValidationError{path, "value is not one of the allowed values"})
+	}
+
+	switch s.Type {
+	case "":
+		return errs
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(errs, typeError(path, s.Type, value))
+		}
+
+		return append(errs, s.validateObject(path, object)...)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return append(errs, typeError(path, s.Type, value))
+		}
+
+		if s.Items != nil {
+			for i, item := range array {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return append(errs, typeError(path, s.Type, value))
+		}
+
+		if s.MinLength != nil && utf8.RuneCountInString(str) < *s.MinLength {
+			errs = append(errs, ValidationError{path, fmt.Sprintf("must be at least %d characters long", *s.MinLength)})
+		}
+
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				errs = append(errs, ValidationError{path, "must be an RFC 3339 date-time"})
+			}
+		}
+	case "number", "integer":
+		number, ok := value.(float64)
+		if !ok {
+			return append(errs, typeError(path, s.Type, value))
+		}
+
+		if s.Type == "integer" && number != math.Trunc(number) {
+			errs = append(errs, typeError(path, s.Type, value))
+		}
+
+		if s.Minimum != nil && number < *s.Minimum {
+			errs = append(errs, ValidationError{path, fmt.Sprintf("must be greater than or equal to %v", *s.Minimum)})
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, typeError(path, s.Type, value))
+		}
+	}
+
+	return errs
+}
+
+func (s *Schema) validateObject(path string, object map[string]interface{}) []ValidationError {
+	errs := []ValidationError{}
+
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, ValidationError{joinPath(path, name), "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		switch {
+		case ok:
+			errs = append(errs, property.validate(joinPath(path, name), object[name])...)
+		case !s.AllowAdditionalProperties:
+			errs = append(errs, ValidationError{joinPath(path, name), "is not a known property"})
+		case s.AdditionalProperties != nil:
+			errs = append(errs, s.AdditionalProperties.validate(joinPath(path, name), object[name])...)
+		}
+	}
+
+	return errs
+}
+
+func typeError(path string, expected string, value interface{}) ValidationError {
+	return ValidationError{path, fmt.Sprintf("expected %s but got %s", expected, jsonType(value))}
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return strings.Join([]string{path, name}, ".")
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+package openapi
+
+import (
+	"encoding/json"
+
+	"github.com/aws-samples/serverless-go-demo/jsonschema"
+)
+
+const Version = "3.0.3"
+
+// Document is the subset of the OpenAPI 3 specification needed to describe the
+// product API.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lowercase HTTP methods to their operation.
+type PathItem map[string]Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// SecurityRequirement maps security scheme names to the scopes they require.
+type SecurityRequirement map[string][]string
+
+type Parameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string             `json:"description,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema SchemaRef `json:"schema"`
+}
+
+// SchemaRef is either a reference to a schema in the components or an inline
+// schema.
+type SchemaRef struct {
+	Ref string `json:"$ref,omitempty"`
+	*jsonschema.Schema
+}
+
+type Components struct {
+	Schemas         map[string]*jsonschema.Schema `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme     `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+func NewDocument(title string, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:   title,
+			Version: version,
+		},
+		Paths: map[string]PathItem{},
+		Components: Components{
+			Schemas:         map[string]*jsonschema.Schema{},
+			SecuritySchemes: map[string]SecurityScheme{},
+		},
+	}
+}
+
+// AddSchema registers a component schema and returns a reference to it.
+func (d *Document) AddSchema(name string, schema *jsonschema.Schema) SchemaRef {
+	d.Components.Schemas[name] = schema
+
+	return SchemaRef{Ref: "#/components/schemas/" + name}
+}
+
+func (d *Document) AddOperation(method string, path string, operation Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+
+	item[method] = operation
+}
+
+func (r SchemaRef) MarshalJSON() ([]byte, error) {
+	if r.Ref != "" {
+		return json.Marshal(map[string]string{"$ref": r.Ref})
+	}
+
+	if r.Schema == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(r.Schema)
+}
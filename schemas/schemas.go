@@ -0,0 +1,121 @@
+// Package schemas holds the versioned JSON Schemas of the data of product
+// events, one directory per detail type with a v<N>.json file per version.
+package schemas
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws-samples/serverless-go-demo/jsonschema"
+)
+
+//go:embed */*.json
+var files embed.FS
+
+// Registry holds every version of the schema of each detail type.
+type Registry struct {
+	// versions holds the schema of version i+1 at index i.
+	versions map[string][]*jsonschema.Schema
+}
+
+// Load reads the schemas embedded in the binary. The versions of a detail type
+// must be numbered from 1 without gaps.
+func Load() (*Registry, error) {
+	return LoadFS(files)
+}
+
+// MustLoad is like Load but panics when the embedded schemas are invalid,
+// which the unit tests rule out.
+func MustLoad() *Registry {
+	r, err := Load()
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+// LoadFS reads schemas laid out like the embedded ones from fsys.
+func LoadFS(fsys fs.FS) (*Registry, error) {
+	paths, err := fs.Glob(fsys, "*/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]map[int]*jsonschema.Schema{}
+	for _, p := range paths {
+		detailType, file := path.Split(p)
+		detailType = strings.TrimSuffix(detailType, "/")
+
+		version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(file, "v"), ".json"))
+		if err != nil || !strings.HasPrefix(file, "v") || version < 1 {
+			return nil, fmt.Errorf("schema file '%s' is not named v<version>.json", p)
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+
+		schema := &jsonschema.Schema{}
+		if err := json.Unmarshal(data, schema); err != nil {
+			return nil, fmt.Errorf("invalid schema '%s': %w", p, err)
+		}
+
+		if found[detailType] == nil {
+			found[detailType] = map[int]*jsonschema.Schema{}
+		}
+		found[detailType][version] = schema
+	}
+
+	r := &Registry{versions: map[string][]*jsonschema.Schema{}}
+	for detailType, schemas := range found {
+		for version := 1; version <= len(schemas); version++ {
+			schema, ok := schemas[version]
+			if !ok {
+				return nil, fmt.Errorf("missing version %d of the %s schema", version, detailType)
+			}
+			r.versions[detailType] = append(r.versions[detailType], schema)
+		}
+	}
+
+	return r, nil
+}
+
+// DetailTypes lists the detail types that have a schema, sorted.
+func (r *Registry) DetailTypes() []string {
+	detailTypes := make([]string, 0, len(r.versions))
+	for detailType := range r.versions {
+		detailTypes = append(detailTypes, detailType)
+	}
+	sort.Strings(detailTypes)
+
+	return detailTypes
+}
+
+// Latest returns the current version of the schema of a detail type, which
+// published events conform to.
+func (r *Registry) Latest(detailType string) (*jsonschema.Schema, int, bool) {
+	versions := r.versions[detailType]
+	if len(versions) == 0 {
+		return nil, 0, false
+	}
+
+	return versions[len(versions)-1], len(versions), true
+}
+
+// Version returns a given version of the schema of a detail type.
+func (r *Registry) Version(detailType string, version int) (*jsonschema.Schema, bool) {
+	versions := r.versions[detailType]
+	if version < 1 || version > len(versions) {
+		return nil, false
+	}
+
+	return versions[version-1], true
+}
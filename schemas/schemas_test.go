@@ -0,0 +1,75 @@
+//go:build unit
+// +build unit
+
+package schemas_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws-samples/serverless-go-demo/domain"
+	"github.com/aws-samples/serverless-go-demo/jsonschema"
+	"github.com/aws-samples/serverless-go-demo/schemas"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// dataTypes are the Go types of the data of each detail type.
+var dataTypes = map[string]interface{}{
+	domain.DetailTypeProductCreated:  types.Product{},
+	domain.DetailTypeProductChanged:  types.ProductChangedDetail{},
+	domain.DetailTypeProductDeleted:  types.Product{},
+	domain.DetailTypePriceChanged:    types.PriceChangedDetail{},
+	domain.DetailTypeProductRenamed:  types.ProductRenamedDetail{},
+	domain.DetailTypeProductUpdated:  types.ProductChangedDetail{},
+	domain.DetailTypeProductDelected: types.Product{},
+}
+
+func TestVersionsAreCompatible(t *testing.T) {
+	registry := schemas.MustLoad()
+
+	for _, detailType := range registry.DetailTypes() {
+		_, latest, _ := registry.Latest(detailType)
+		for version := 2; version <= latest; version++ {
+			old, _ := registry.Version(detailType, version-1)
+			new, _ := registry.Version(detailType, version)
+
+			for _, change := range jsonschema.Breaking(jsonschema.Compare(old, new)) {
+				t.Errorf("%s version %d breaks version %d: %s", detailType, version, version-1, change)
+			}
+		}
+	}
+}
+
+// TestLatestVersionsDescribeTypes fails when the Go types of event data no
+// longer match the latest schemas. Compatible changes need a new version of
+// the schema, and breaking ones a new detail type.
+func TestLatestVersionsDescribeTypes(t *testing.T) {
+	registry := schemas.MustLoad()
+
+	for detailType, dataType := range dataTypes {
+		latest, version, ok := registry.Latest(detailType)
+		if !ok {
+			t.Errorf("No schema for %s", detailType)
+			continue
+		}
+
+		for _, change := range jsonschema.Compare(latest, jsonschema.Reflect(dataType)) {
+			t.Errorf("%s data differs from version %d of its schema, %s", detailType, version, change)
+		}
+	}
+
+	if detailTypes := registry.DetailTypes(); len(detailTypes) != len(dataTypes) {
+		t.Errorf("Got schemas for %v, expected one for each detail type", detailTypes)
+	}
+}
+
+func TestLoadRejectsMissingVersions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ProductCreated/v1.json": {Data: []byte(`{"type": "object"}`)},
+		"ProductCreated/v3.json": {Data: []byte(`{"type": "object"}`)},
+	}
+
+	if _, err := schemas.LoadFS(fsys); err == nil {
+		t.Error("LoadFS accepted schemas without version 2")
+	}
+}
@@ -2,8 +2,12 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -14,14 +18,31 @@ import (
 	"github.com/aws-samples/serverless-go-demo/types"
 )
 
+// maxOutboxAttempts is how many times a change is written with its outbox
+// item when the product keeps changing in between.
+const maxOutboxAttempts = 3
+
 type DynamoDBStore struct {
-	client    *dynamodb.Client
-	tableName string
+	client      *dynamodb.Client
+	tableName   string
+	outboxTable string
+	now         func() time.Time
 }
 
-var _ types.Store = (*DynamoDBStore)(nil)
+var _ types.ConditionalStore = (*DynamoDBStore)(nil)
+var _ types.VersionedStore = (*DynamoDBStore)(nil)
+
+type DynamoDBStoreOption func(*DynamoDBStore)
+
+// WithOutboxTable writes every change to the outbox table too, in the same
+// transaction, for deployments where the table stream can't be used.
+func WithOutboxTable(name string) DynamoDBStoreOption {
+	return func(d *DynamoDBStore) {
+		d.outboxTable = name
+	}
+}
 
-func NewDynamoDBStore(ctx context.Context, tableName string) *DynamoDBStore {
+func NewDynamoDBStore(ctx context.Context, tableName string, opts ...DynamoDBStoreOption) *DynamoDBStore {
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
 		log.Fatalf("unable to load SDK config, %v", err)
@@ -29,10 +50,17 @@ func NewDynamoDBStore(ctx context.Context, tableName string) *DynamoDBStore {
 
 	client := dynamodb.NewFromConfig(cfg)
 
-	return &DynamoDBStore{
+	d := &DynamoDBStore{
 		client:    client,
 		tableName: tableName,
+		now:       time.Now,
 	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
 func (d *DynamoDBStore) All(ctx context.Context, next *string) (types.ProductRange, error) {
@@ -57,11 +85,18 @@ func (d *DynamoDBStore) All(ctx context.Context, next *string) (types.ProductRan
 		return productRange, fmt.Errorf("failed to get items from DynamoDB: %w", err)
 	}
 
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &productRange.Products)
+	products := []types.Product{}
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &products)
 	if err != nil {
 		return productRange, fmt.Errorf("failed to unmarshal data from DynamoDB: %w", err)
 	}
 
+	for _, product := range products {
+		if !product.Deleted {
+			productRange.Products = append(productRange.Products, product)
+		}
+	}
+
 	if len(result.LastEvaluatedKey) > 0 {
 		if key, ok := result.LastEvaluatedKey["id"]; ok {
 			nextKey := key.(*ddbtypes.AttributeValueMemberS).Value
@@ -73,11 +108,26 @@ func (d *DynamoDBStore) All(ctx context.Context, next *string) (types.ProductRan
 }
 
 func (d *DynamoDBStore) Get(ctx context.Context, id string) (*types.Product, error) {
+	product, err := d.getItem(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil || product.Deleted {
+		return nil, nil
+	}
+
+	return product, nil
+}
+
+// getItem gets a product, tombstones included.
+func (d *DynamoDBStore) getItem(ctx context.Context, id string, consistent bool) (*types.Product, error) {
 	response, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &d.tableName,
 		Key: map[string]ddbtypes.AttributeValue{
 			"id": &ddbtypes.AttributeValueMemberS{Value: id},
 		},
+		ConsistentRead: aws.Bool(consistent),
 	})
 
 	if err != nil {
@@ -99,6 +149,11 @@ func (d *DynamoDBStore) Get(ctx context.Context, id string) (*types.Product, err
 }
 
 func (d *DynamoDBStore) Put(ctx context.Context, product types.Product) error {
+	if d.outboxTable != "" {
+		_, err := d.writeWithOutbox(ctx, product.Id, &product, nil)
+		return err
+	}
+
 	item, err := attributevalue.MarshalMap(&product)
 	if err != nil {
 		return fmt.Errorf("unable to marshal product: %w", err)
@@ -117,6 +172,11 @@ func (d *DynamoDBStore) Put(ctx context.Context, product types.Product) error {
 }
 
 func (d *DynamoDBStore) Delete(ctx context.Context, id string) error {
+	if d.outboxTable != "" {
+		_, err := d.writeWithOutbox(ctx, id, nil, nil)
+		return err
+	}
+
 	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &d.tableName,
 		Key: map[string]ddbtypes.AttributeValue{
@@ -130,3 +190,296 @@ func (d *DynamoDBStore) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// PutIfChanged puts the product on the condition that it is new, or that one
+// of the attributes that aren't ignored differs from the stored item, so that
+// unchanged products are neither written nor streamed.
+func (d *DynamoDBStore) PutIfChanged(ctx context.Context, product types.Product, ignoredFields []string) (bool, error) {
+	if d.outboxTable != "" {
+		return d.writeWithOutbox(ctx, product.Id, &product, func(before *types.Product) bool {
+			return before != nil && types.Unchanged(*before, product, ignoredFields)
+		})
+	}
+
+	item, err := attributevalue.MarshalMap(&product)
+	if err != nil {
+		return false, fmt.Errorf("unable to marshal product: %w", err)
+	}
+
+	conditions := []string{"attribute_not_exists(id)"}
+	names := map[string]string{}
+	values := map[string]ddbtypes.AttributeValue{}
+
+	for i, attribute := range comparedAttributes(ignoredFields) {
+		name := fmt.Sprintf("#a%d", i)
+		names[name] = attribute
+
+		value, ok := item[attribute]
+		if !ok {
+			conditions = append(conditions, fmt.Sprintf("attribute_exists(%s)", name))
+			continue
+		}
+
+		placeholder := fmt.Sprintf(":a%d", i)
+		values[placeholder] = value
+		conditions = append(conditions, fmt.Sprintf("attribute_not_exists(%s) OR %s <> %s", name, name, placeholder))
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:                &d.tableName,
+		Item:                     item,
+		ConditionExpression:      aws.String(strings.Join(conditions, " OR ")),
+		ExpressionAttributeNames: names,
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+
+	_, err = d.client.PutItem(ctx, input)
+
+	var conditionFailed *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("cannot put item: %w", err)
+	}
+
+	return true, nil
+}
+
+// PutIfUnmodified puts the product on the condition that the stored item,
+// tombstones included, was last updated at updatedAt.
+func (d *DynamoDBStore) PutIfUnmodified(ctx context.Context, product types.Product, updatedAt *time.Time) (bool, error) {
+	if d.outboxTable != "" {
+		return d.writeWithOutbox(ctx, product.Id, &product, func(before *types.Product) bool {
+			return !types.Unmodified(before, updatedAt)
+		})
+	}
+
+	item, err := attributevalue.MarshalMap(&product)
+	if err != nil {
+		return false, fmt.Errorf("unable to marshal product: %w", err)
+	}
+
+	condition, values, err := unmodifiedCondition(updatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 &d.tableName,
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+
+	var conditionFailed *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("cannot put item: %w", err)
+	}
+
+	return true, nil
+}
+
+// DeleteIfUnmodified deletes the product on the same condition as
+// PutIfUnmodified.
+func (d *DynamoDBStore) DeleteIfUnmodified(ctx context.Context, id string, updatedAt *time.Time) (bool, error) {
+	if d.outboxTable != "" {
+		return d.writeWithOutbox(ctx, id, nil, func(before *types.Product) bool {
+			return !types.Unmodified(before, updatedAt)
+		})
+	}
+
+	condition, values, err := unmodifiedCondition(updatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &d.tableName,
+		Key: map[string]ddbtypes.AttributeValue{
+			"id": &ddbtypes.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+
+	var conditionFailed *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("can't delete item: %w", err)
+	}
+
+	return true, nil
+}
+
+// comparedAttributes lists the DynamoDB names of the product attributes,
+// except the key and the ignored fields, given by their JSON names.
+func comparedAttributes(ignoredFields []string) []string {
+	attributes := []string{}
+
+	productType := reflect.TypeOf(types.Product{})
+	for i := 0; i < productType.NumField(); i++ {
+		field := productType.Field(i)
+		attribute := strings.Split(field.Tag.Get("dynamodbav"), ",")[0]
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if attribute == "" || attribute == "-" || attribute == "id" || containsField(ignoredFields, name) {
+			continue
+		}
+
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeWithOutbox puts the product, or deletes it when product is nil, and
+// writes the change to the outbox in a single transaction, unless skip tells
+// the write isn't needed given the stored product. The write is conditioned
+// on the product read to describe the change, and starts over when the
+// product changed in between. It tells whether anything was written.
+func (d *DynamoDBStore) writeWithOutbox(ctx context.Context, id string, product *types.Product, skip func(before *types.Product) bool) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		before, err := d.getItem(ctx, id, true)
+		if err != nil {
+			return false, err
+		}
+
+		if before == nil && product == nil {
+			return false, nil
+		}
+
+		if skip != nil && skip(before) {
+			return false, nil
+		}
+
+		change, err := newProductChange(before, product, d.now())
+		if err != nil {
+			return false, err
+		}
+
+		outboxItem, err := attributevalue.MarshalMap(&change)
+		if err != nil {
+			return false, fmt.Errorf("unable to marshal outbox item: %w", err)
+		}
+		outboxItem[outboxStatusAttribute] = &ddbtypes.AttributeValueMemberS{Value: outboxStatusPending}
+
+		write, err := d.conditionalWrite(id, before, product)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = d.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []ddbtypes.TransactWriteItem{
+				write,
+				{
+					Put: &ddbtypes.Put{
+						TableName: &d.outboxTable,
+						Item:      outboxItem,
+					},
+				},
+			},
+		})
+		if err == nil {
+			return true, nil
+		}
+
+		if !isConditionalCheckFailed(err) || attempt == maxOutboxAttempts {
+			return false, fmt.Errorf("cannot write item with its outbox item: %w", err)
+		}
+	}
+}
+
+// conditionalWrite puts or deletes the product, on the condition that it is
+// still as it was before.
+func (d *DynamoDBStore) conditionalWrite(id string, before *types.Product, product *types.Product) (ddbtypes.TransactWriteItem, error) {
+	var condition string
+	var values map[string]ddbtypes.AttributeValue
+
+	if before == nil {
+		condition = "attribute_not_exists(id)"
+	} else {
+		var err error
+		condition, values, err = unmodifiedCondition(before.UpdatedAt)
+		if err != nil {
+			return ddbtypes.TransactWriteItem{}, err
+		}
+	}
+
+	if product == nil {
+		return ddbtypes.TransactWriteItem{
+			Delete: &ddbtypes.Delete{
+				TableName: &d.tableName,
+				Key: map[string]ddbtypes.AttributeValue{
+					"id": &ddbtypes.AttributeValueMemberS{Value: id},
+				},
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeValues: values,
+			},
+		}, nil
+	}
+
+	item, err := attributevalue.MarshalMap(product)
+	if err != nil {
+		return ddbtypes.TransactWriteItem{}, fmt.Errorf("unable to marshal product: %w", err)
+	}
+
+	return ddbtypes.TransactWriteItem{
+		Put: &ddbtypes.Put{
+			TableName:                 &d.tableName,
+			Item:                      item,
+			ConditionExpression:       aws.String(condition),
+			ExpressionAttributeValues: values,
+		},
+	}, nil
+}
+
+// unmodifiedCondition is the condition that the item exists and was last
+// updated at updatedAt.
+func unmodifiedCondition(updatedAt *time.Time) (string, map[string]ddbtypes.AttributeValue, error) {
+	if updatedAt == nil {
+		return "attribute_exists(id) AND attribute_not_exists(updatedAt)", nil, nil
+	}
+
+	value, err := attributevalue.Marshal(updatedAt)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to marshal updatedAt: %w", err)
+	}
+
+	return "updatedAt = :updatedAt", map[string]ddbtypes.AttributeValue{":updatedAt": value}, nil
+}
+
+func isConditionalCheckFailed(err error) bool {
+	var canceled *ddbtypes.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return false
+	}
+
+	for _, reason := range canceled.CancellationReasons {
+		if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+type DynamoDBAPIKeyStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+var _ types.APIKeyStore = (*DynamoDBAPIKeyStore)(nil)
+
+func NewDynamoDBAPIKeyStore(ctx context.Context, tableName string) *DynamoDBAPIKeyStore {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+
+	return &DynamoDBAPIKeyStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (d *DynamoDBAPIKeyStore) Get(ctx context.Context, id string) (*types.APIKey, error) {
+	response, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &d.tableName,
+		Key: map[string]ddbtypes.AttributeValue{
+			"id": &ddbtypes.AttributeValueMemberS{Value: id},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key from DynamoDB: %w", err)
+	}
+
+	if len(response.Item) == 0 {
+		return nil, nil
+	}
+
+	key := types.APIKey{}
+	err = attributevalue.UnmarshalMap(response.Item, &key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (d *DynamoDBAPIKeyStore) Put(ctx context.Context, key types.APIKey) error {
+	item, err := attributevalue.MarshalMap(&key)
+	if err != nil {
+		return fmt.Errorf("unable to marshal API key: %w", err)
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &d.tableName,
+		Item:      item,
+	})
+
+	if err != nil {
+		return fmt.Errorf("cannot put API key: %w", err)
+	}
+
+	return nil
+}
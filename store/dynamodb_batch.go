@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// maxBatchWriteItems is the number of requests BatchWriteItem accepts at once.
+	maxBatchWriteItems = 25
+	// maxBatchWriteAttempts is how many times unprocessed requests are sent.
+	maxBatchWriteAttempts = 3
+)
+
+// batchWrite sends the write requests to the table in as many BatchWriteItem
+// calls as needed, resending those left unprocessed.
+func batchWrite(ctx context.Context, client *dynamodb.Client, tableName string, requests []ddbtypes.WriteRequest) error {
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		end := start + maxBatchWriteItems
+		if end > len(requests) {
+			end = len(requests)
+		}
+
+		unprocessed := map[string][]ddbtypes.WriteRequest{tableName: requests[start:end]}
+		for attempt := 0; attempt < maxBatchWriteAttempts && len(unprocessed) > 0; attempt++ {
+			result, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: unprocessed,
+			})
+			if err != nil {
+				return err
+			}
+			unprocessed = result.UnprocessedItems
+		}
+
+		if len(unprocessed) > 0 {
+			return fmt.Errorf("%d requests left unprocessed", len(unprocessed[tableName]))
+		}
+	}
+
+	return nil
+}
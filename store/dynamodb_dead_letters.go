@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// DynamoDBDeadLetterStore keeps dead letters in a table keyed by their id.
+type DynamoDBDeadLetterStore struct {
+	client    *dynamodb.Client
+	tableName string
+	fallback  types.DeadLetterSink
+}
+
+// maxDeadLetterSize keeps dead letters below the 400 KB item limit of
+// DynamoDB, with room for the attribute names.
+const maxDeadLetterSize = 350 * 1024
+
+var _ types.DeadLetterStore = (*DynamoDBDeadLetterStore)(nil)
+
+func NewDynamoDBDeadLetterStore(ctx context.Context, tableName string) *DynamoDBDeadLetterStore {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+
+	return &DynamoDBDeadLetterStore{
+		client:    client,
+		tableName: tableName,
+		fallback:  NewLogDeadLetterSink(nil),
+	}
+}
+
+// Send puts dead letters one at a time, so that one that cannot be written
+// does not hold back the others. Those that still fail are logged instead.
+func (d *DynamoDBDeadLetterStore) Send(ctx context.Context, deadLetters []types.DeadLetter) error {
+	for _, deadLetter := range deadLetters {
+		deadLetter = truncateDeadLetter(deadLetter, maxDeadLetterSize)
+
+		item, err := attributevalue.MarshalMap(&deadLetter)
+		if err == nil {
+			_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName: &d.tableName,
+				Item:      item,
+			})
+		}
+
+		if err != nil {
+			log.Printf("cannot put dead letter %s, logging it instead: %v", deadLetter.Id, err)
+
+			if err := d.fallback.Send(ctx, []types.DeadLetter{deadLetter}); err != nil {
+				return fmt.Errorf("cannot put dead letter %s: %w", deadLetter.Id, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// List scans the whole table, which only holds the records that could not be
+// published.
+func (d *DynamoDBDeadLetterStore) List(ctx context.Context, filter types.DeadLetterFilter) ([]types.DeadLetter, error) {
+	deadLetters := []types.DeadLetter{}
+
+	input := &dynamodb.ScanInput{
+		TableName:      &d.tableName,
+		ConsistentRead: aws.Bool(true),
+	}
+
+	for {
+		result, err := d.client.Scan(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dead letters from DynamoDB: %w", err)
+		}
+
+		page := []types.DeadLetter{}
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal dead letters from DynamoDB: %w", err)
+		}
+
+		for _, deadLetter := range page {
+			if filter.Match(deadLetter) {
+				deadLetters = append(deadLetters, deadLetter)
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	sortDeadLetters(deadLetters)
+
+	return deadLetters, nil
+}
+
+func (d *DynamoDBDeadLetterStore) Delete(ctx context.Context, ids []string) error {
+	requests := make([]ddbtypes.WriteRequest, len(ids))
+	for i, id := range ids {
+		requests[i] = ddbtypes.WriteRequest{
+			DeleteRequest: &ddbtypes.DeleteRequest{
+				Key: map[string]ddbtypes.AttributeValue{
+					"id": &ddbtypes.AttributeValueMemberS{Value: id},
+				},
+			},
+		}
+	}
+
+	if err := batchWrite(ctx, d.client, d.tableName, requests); err != nil {
+		return fmt.Errorf("can't delete dead letters: %w", err)
+	}
+
+	return nil
+}
+
+func sortDeadLetters(deadLetters []types.DeadLetter) {
+	sort.SliceStable(deadLetters, func(i, j int) bool {
+		if !deadLetters[i].FailedAt.Equal(deadLetters[j].FailedAt) {
+			return deadLetters[i].FailedAt.Before(deadLetters[j].FailedAt)
+		}
+		return deadLetters[i].Id < deadLetters[j].Id
+	})
+}
+
+// truncateDeadLetter leaves out the raw record of a dead letter larger than
+// maxSize, then its events, which are needed to replay it, if it is still too
+// large.
+func truncateDeadLetter(deadLetter types.DeadLetter, maxSize int) types.DeadLetter {
+	if deadLetterSize(deadLetter) <= maxSize {
+		return deadLetter
+	}
+
+	deadLetter.Record = nil
+	deadLetter.Truncated = true
+	if deadLetterSize(deadLetter) <= maxSize {
+		return deadLetter
+	}
+
+	deadLetter.Events = nil
+
+	return deadLetter
+}
+
+// deadLetterSize estimates the size of the item of a dead letter from its
+// JSON encoding.
+func deadLetterSize(deadLetter types.DeadLetter) int {
+	data, err := json.Marshal(deadLetter)
+	if err != nil {
+		return 0
+	}
+
+	return len(data)
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// DynamoDBIdempotencyStore keeps the first response for every idempotency key.
+// The table needs a "key" partition key and TTL enabled on "expiresAt".
+type DynamoDBIdempotencyStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+var _ types.IdempotencyStore = (*DynamoDBIdempotencyStore)(nil)
+
+func NewDynamoDBIdempotencyStore(ctx context.Context, tableName string) *DynamoDBIdempotencyStore {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+
+	return &DynamoDBIdempotencyStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (d *DynamoDBIdempotencyStore) Get(ctx context.Context, key string) (*types.IdempotencyRecord, error) {
+	response, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &d.tableName,
+		Key: map[string]ddbtypes.AttributeValue{
+			"key": &ddbtypes.AttributeValueMemberS{Value: key},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record from DynamoDB: %w", err)
+	}
+
+	if len(response.Item) == 0 {
+		return nil, nil
+	}
+
+	record := types.IdempotencyRecord{}
+	err = attributevalue.UnmarshalMap(response.Item, &record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+	}
+
+	// TTL deletion is lazy, so expired items can still be returned for a while.
+	if record.ExpiresAt <= time.Now().Unix() {
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+func (d *DynamoDBIdempotencyStore) Reserve(ctx context.Context, record types.IdempotencyRecord) (bool, error) {
+	item, err := attributevalue.MarshalMap(&record)
+	if err != nil {
+		return false, fmt.Errorf("unable to marshal idempotency record: %w", err)
+	}
+
+	// A key is free when it was never used or its previous record expired.
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &d.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #expiresAt <= :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#key":       "key",
+			"#expiresAt": "expiresAt",
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":now": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+		},
+	})
+
+	var conditionErr *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("cannot reserve idempotency key: %w", err)
+	}
+
+	return true, nil
+}
+
+func (d *DynamoDBIdempotencyStore) Put(ctx context.Context, record types.IdempotencyRecord) error {
+	item, err := attributevalue.MarshalMap(&record)
+	if err != nil {
+		return fmt.Errorf("unable to marshal idempotency record: %w", err)
+	}
+
+	// The key must still be held by the same request. It no longer is when the
+	// reservation expired and the key was reserved again for another request.
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &d.tableName,
+		Item:                item,
+		ConditionExpression: aws.String("#fingerprint = :fingerprint"),
+		ExpressionAttributeNames: map[string]string{
+			"#fingerprint": "fingerprint",
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":fingerprint": &ddbtypes.AttributeValueMemberS{Value: record.Fingerprint},
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("cannot put idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DynamoDBIdempotencyStore) Delete(ctx context.Context, key string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &d.tableName,
+		Key: map[string]ddbtypes.AttributeValue{
+			"key": &ddbtypes.AttributeValueMemberS{Value: key},
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("cannot delete idempotency record: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// DynamoDBOutbox reads the outbox table written by a DynamoDBStore set up
+// with WithOutboxTable.
+type DynamoDBOutbox struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+var _ types.Outbox = (*DynamoDBOutbox)(nil)
+
+// Outbox items carry a status attribute, always pending, which is the
+// partition key of the pending index, with the change id as sort key.
+const (
+	outboxPendingIndex    = "PendingIndex"
+	outboxStatusAttribute = "status"
+	outboxStatusPending   = "pending"
+)
+
+func NewDynamoDBOutbox(ctx context.Context, tableName string) *DynamoDBOutbox {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+
+	return &DynamoDBOutbox{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Pending queries the pending index, whose single partition holds every
+// change sorted by id, and so by time. The index is eventually consistent, so
+// a change can show up a little after it is written, or once more after it
+// is removed, which the at least once delivery allows for.
+func (d *DynamoDBOutbox) Pending(ctx context.Context, limit int) ([]types.ProductChange, error) {
+	changes := []types.ProductChange{}
+
+	input := &dynamodb.QueryInput{
+		TableName:              &d.tableName,
+		IndexName:              aws.String(outboxPendingIndex),
+		KeyConditionExpression: aws.String("#status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": outboxStatusAttribute,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":pending": &ddbtypes.AttributeValueMemberS{Value: outboxStatusPending},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}
+
+	for len(changes) < limit {
+		input.Limit = aws.Int32(int32(limit - len(changes)))
+
+		result, err := d.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get outbox items from DynamoDB: %w", err)
+		}
+
+		page := []types.ProductChange{}
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal outbox items from DynamoDB: %w", err)
+		}
+		changes = append(changes, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return changes, nil
+}
+
+func (d *DynamoDBOutbox) Remove(ctx context.Context, ids []string) error {
+	requests := make([]ddbtypes.WriteRequest, len(ids))
+	for i, id := range ids {
+		requests[i] = ddbtypes.WriteRequest{
+			DeleteRequest: &ddbtypes.DeleteRequest{
+				Key: map[string]ddbtypes.AttributeValue{
+					"id": &ddbtypes.AttributeValueMemberS{Value: id},
+				},
+			},
+		}
+	}
+
+	if err := batchWrite(ctx, d.client, d.tableName, requests); err != nil {
+		return fmt.Errorf("can't delete outbox items: %w", err)
+	}
+
+	return nil
+}
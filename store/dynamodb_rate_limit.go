@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// DynamoDBRateLimitStore keeps token buckets in a table with a "key" partition
+// key and TTL enabled on "expiresAt". Updates use optimistic locking on the
+// bucket version.
+type DynamoDBRateLimitStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+var _ types.RateLimitStore = (*DynamoDBRateLimitStore)(nil)
+
+func NewDynamoDBRateLimitStore(ctx context.Context, tableName string) *DynamoDBRateLimitStore {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+
+	return &DynamoDBRateLimitStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (d *DynamoDBRateLimitStore) Get(ctx context.Context, key string) (*types.TokenBucket, error) {
+	response, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &d.tableName,
+		Key: map[string]ddbtypes.AttributeValue{
+			"key": &ddbtypes.AttributeValueMemberS{Value: key},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token bucket from DynamoDB: %w", err)
+	}
+
+	if len(response.Item) == 0 {
+		return nil, nil
+	}
+
+	bucket := types.TokenBucket{}
+	err = attributevalue.UnmarshalMap(response.Item, &bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token bucket: %w", err)
+	}
+
+	return &bucket, nil
+}
+
+func (d *DynamoDBRateLimitStore) Put(ctx context.Context, bucket types.TokenBucket, expectedVersion int64) error {
+	item, err := attributevalue.MarshalMap(&bucket)
+	if err != nil {
+		return fmt.Errorf("unable to marshal token bucket: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: &d.tableName,
+		Item:      item,
+	}
+
+	if expectedVersion == 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(#key)")
+		input.ExpressionAttributeNames = map[string]string{"#key": "key"}
+	} else {
+		input.ConditionExpression = aws.String("#version = :version")
+		input.ExpressionAttributeNames = map[string]string{"#version": "version"}
+		input.ExpressionAttributeValues = map[string]ddbtypes.AttributeValue{
+			":version": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(expectedVersion, 10)},
+		}
+	}
+
+	_, err = d.client.PutItem(ctx, input)
+
+	var conditionErr *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return types.ErrBucketConflict
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot put token bucket: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// DynamoDBSearchIndex keeps one item per posting in a table keyed by term
+// and product id, so that the postings of a term are read with one query.
+type DynamoDBSearchIndex struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+var _ types.SearchIndex = (*DynamoDBSearchIndex)(nil)
+
+func NewDynamoDBSearchIndex(ctx context.Context, tableName string) *DynamoDBSearchIndex {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+
+	return &DynamoDBSearchIndex{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Write sends the removals and the puts in the same batches, so a posting
+// must not be both removed and put.
+func (d *DynamoDBSearchIndex) Write(ctx context.Context, put []types.SearchPosting, remove []types.SearchPosting) error {
+	requests := make([]ddbtypes.WriteRequest, 0, len(put)+len(remove))
+
+	for _, posting := range remove {
+		requests = append(requests, ddbtypes.WriteRequest{
+			DeleteRequest: &ddbtypes.DeleteRequest{Key: postingKey(posting)},
+		})
+	}
+
+	for _, posting := range put {
+		item, err := attributevalue.MarshalMap(posting)
+		if err != nil {
+			return fmt.Errorf("unable to marshal posting: %w", err)
+		}
+
+		requests = append(requests, ddbtypes.WriteRequest{
+			PutRequest: &ddbtypes.PutRequest{Item: item},
+		})
+	}
+
+	if err := batchWrite(ctx, d.client, d.tableName, requests); err != nil {
+		return fmt.Errorf("can't write postings: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DynamoDBSearchIndex) Postings(ctx context.Context, terms []string) ([]types.SearchPosting, error) {
+	postings := []types.SearchPosting{}
+
+	for _, term := range terms {
+		input := &dynamodb.QueryInput{
+			TableName:              &d.tableName,
+			KeyConditionExpression: aws.String("#term = :term"),
+			ExpressionAttributeNames: map[string]string{
+				"#term": "term",
+			},
+			ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+				":term": &ddbtypes.AttributeValueMemberS{Value: term},
+			},
+		}
+
+		for {
+			result, err := d.client.Query(ctx, input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get postings from DynamoDB: %w", err)
+			}
+
+			page := []types.SearchPosting{}
+			if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal postings from DynamoDB: %w", err)
+			}
+			postings = append(postings, page...)
+
+			if len(result.LastEvaluatedKey) == 0 {
+				break
+			}
+			input.ExclusiveStartKey = result.LastEvaluatedKey
+		}
+	}
+
+	return postings, nil
+}
+
+// Clear scans the whole table and deletes its items page by page.
+func (d *DynamoDBSearchIndex) Clear(ctx context.Context) error {
+	input := &dynamodb.ScanInput{
+		TableName:            &d.tableName,
+		ProjectionExpression: aws.String("#term, id"),
+		ExpressionAttributeNames: map[string]string{
+			"#term": "term",
+		},
+	}
+
+	for {
+		result, err := d.client.Scan(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to get postings from DynamoDB: %w", err)
+		}
+
+		postings := []types.SearchPosting{}
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &postings); err != nil {
+			return fmt.Errorf("failed to unmarshal postings from DynamoDB: %w", err)
+		}
+
+		if err := d.Write(ctx, nil, postings); err != nil {
+			return err
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+func postingKey(posting types.SearchPosting) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		"term": &ddbtypes.AttributeValueMemberS{Value: posting.Term},
+		"id":   &ddbtypes.AttributeValueMemberS{Value: posting.Id},
+	}
+}
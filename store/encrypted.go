@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/encryption"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// EncryptedStore encrypts the configured product attributes before they reach
+// the underlying store and decrypts them when they are read back. All the
+// attributes of a product are encrypted with the same data key, and each value
+// is bound to its product and attribute name. Values that aren't encrypted
+// yet, such as those written before an attribute was configured, are returned
+// as they are.
+type EncryptedStore struct {
+	store      types.Store
+	encrypter  *encryption.Encrypter
+	attributes []string
+}
+
+var _ types.ConditionalStore = (*EncryptedStore)(nil)
+var _ types.VersionedStore = (*EncryptedStore)(nil)
+
+func NewEncryptedStore(s types.Store, p encryption.KeyProvider, attributes ...string) *EncryptedStore {
+	return &EncryptedStore{
+		store:      s,
+		encrypter:  encryption.NewEncrypter(p),
+		attributes: attributes,
+	}
+}
+
+func (e *EncryptedStore) All(ctx context.Context, next *string) (types.ProductRange, error) {
+	productRange, err := e.store.All(ctx, next)
+	if err != nil {
+		return productRange, err
+	}
+
+	for i := range productRange.Products {
+		if err := e.decrypt(ctx, &productRange.Products[i]); err != nil {
+			return productRange, err
+		}
+	}
+
+	return productRange, nil
+}
+
+func (e *EncryptedStore) Get(ctx context.Context, id string) (*types.Product, error) {
+	product, err := e.store.Get(ctx, id)
+	if err != nil || product == nil {
+		return product, err
+	}
+
+	if err := e.decrypt(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (e *EncryptedStore) Put(ctx context.Context, product types.Product) error {
+	product, err := e.encrypt(ctx, product)
+	if err != nil {
+		return err
+	}
+
+	return e.store.Put(ctx, product)
+}
+
+// encrypt returns the product with its encrypted attributes sealed.
+func (e *EncryptedStore) encrypt(ctx context.Context, product types.Product) (types.Product, error) {
+	if !e.hasEncryptedAttributes(product) {
+		return product, nil
+	}
+
+	key, err := e.encrypter.NewDataKey(ctx)
+	if err != nil {
+		return product, fmt.Errorf("unable to encrypt product attributes: %w", err)
+	}
+
+	// The caller's map is left untouched, so that it keeps the plaintext values.
+	attributes := copyAttributes(product.Attributes)
+
+	for _, name := range e.attributes {
+		value, ok := attributes[name]
+		if !ok {
+			continue
+		}
+
+		sealed, err := e.encrypter.Seal(key, []byte(value), additionalData(product.Id, name))
+		if err != nil {
+			return product, fmt.Errorf("unable to encrypt attribute '%s': %w", name, err)
+		}
+		attributes[name] = sealed
+	}
+
+	product.Attributes = attributes
+
+	return product, nil
+}
+
+// PutIfChanged compares the product with the decrypted stored one, as the
+// encrypted values differ on every write. Unlike the comparison of the other
+// stores, this one isn't atomic with the write.
+func (e *EncryptedStore) PutIfChanged(ctx context.Context, product types.Product, ignoredFields []string) (bool, error) {
+	stored, err := e.Get(ctx, product.Id)
+	if err != nil {
+		return false, err
+	}
+
+	if stored != nil && types.Unchanged(*stored, product, ignoredFields) {
+		return false, nil
+	}
+
+	if err := e.Put(ctx, product); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (e *EncryptedStore) Delete(ctx context.Context, id string) error {
+	return e.store.Delete(ctx, id)
+}
+
+// PutIfUnmodified and DeleteIfUnmodified are only conditional when the
+// wrapped store is a types.VersionedStore.
+func (e *EncryptedStore) PutIfUnmodified(ctx context.Context, product types.Product, updatedAt *time.Time) (bool, error) {
+	product, err := e.encrypt(ctx, product)
+	if err != nil {
+		return false, err
+	}
+
+	versionedStore, ok := e.store.(types.VersionedStore)
+	if !ok {
+		return true, e.store.Put(ctx, product)
+	}
+
+	return versionedStore.PutIfUnmodified(ctx, product, updatedAt)
+}
+
+func (e *EncryptedStore) DeleteIfUnmodified(ctx context.Context, id string, updatedAt *time.Time) (bool, error) {
+	versionedStore, ok := e.store.(types.VersionedStore)
+	if !ok {
+		return true, e.store.Delete(ctx, id)
+	}
+
+	return versionedStore.DeleteIfUnmodified(ctx, id, updatedAt)
+}
+
+func (e *EncryptedStore) decrypt(ctx context.Context, product *types.Product) error {
+	if !e.hasEncryptedAttributes(*product) {
+		return nil
+	}
+
+	// The map may be shared with the underlying store, which must keep the
+	// encrypted values.
+	attributes := copyAttributes(product.Attributes)
+
+	for _, name := range e.attributes {
+		value, ok := attributes[name]
+		if !ok || !encryption.IsSealed(value) {
+			continue
+		}
+
+		plaintext, err := e.encrypter.Open(ctx, value, additionalData(product.Id, name))
+		if err != nil {
+			return fmt.Errorf("unable to decrypt attribute '%s' of product '%s': %w", name, product.Id, err)
+		}
+		attributes[name] = string(plaintext)
+	}
+
+	product.Attributes = attributes
+
+	return nil
+}
+
+func (e *EncryptedStore) hasEncryptedAttributes(product types.Product) bool {
+	for _, name := range e.attributes {
+		if _, ok := product.Attributes[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyAttributes(attributes map[string]string) map[string]string {
+	copied := make(map[string]string, len(attributes))
+	for name, value := range attributes {
+		copied[name] = value
+	}
+
+	return copied
+}
+
+func additionalData(id string, attribute string) []byte {
+	return []byte(id + "\x00" + attribute)
+}
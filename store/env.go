@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws-samples/serverless-go-demo/encryption"
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// NewProductStoreFromEnv returns the DynamoDB store of the table, wrapped in
+// an EncryptedStore when ENCRYPTED_ATTRIBUTES names attributes to encrypt with
+// the keys given by KMS_KEY_ID or ENCRYPTION_KEY_FILE.
+func NewProductStoreFromEnv(ctx context.Context, tableName string, opts ...DynamoDBStoreOption) (types.Store, error) {
+	var productStore types.Store = NewDynamoDBStore(ctx, tableName, opts...)
+
+	attributes := os.Getenv("ENCRYPTED_ATTRIBUTES")
+	if attributes == "" {
+		return productStore, nil
+	}
+
+	provider, err := encryption.NewKeyProviderFromEnv(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load encryption keys: %w", err)
+	}
+	if provider == nil {
+		return nil, errors.New("need KMS_KEY_ID or ENCRYPTION_KEY_FILE environment variable to encrypt ENCRYPTED_ATTRIBUTES")
+	}
+
+	return NewEncryptedStore(productStore, provider, strings.Split(attributes, ",")...), nil
+}
+
+// NewAPIKeyStoreFromEnv returns the DynamoDB store of the API_KEYS_TABLE
+// table, or nil when it is not set.
+func NewAPIKeyStoreFromEnv(ctx context.Context) types.APIKeyStore {
+	tableName := os.Getenv("API_KEYS_TABLE")
+	if tableName == "" {
+		return nil
+	}
+
+	return NewDynamoDBAPIKeyStore(ctx, tableName)
+}
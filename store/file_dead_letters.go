@@ -0,0 +1,174 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// FileDeadLetterStore keeps dead letters in a local file, one JSON object per
+// line. A dead letter sent again replaces the previous one with the same id.
+type FileDeadLetterStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+var _ types.DeadLetterStore = (*FileDeadLetterStore)(nil)
+
+func NewFileDeadLetterStore(path string) *FileDeadLetterStore {
+	return &FileDeadLetterStore{
+		path: path,
+	}
+}
+
+func (f *FileDeadLetterStore) Send(ctx context.Context, deadLetters []types.DeadLetter) error {
+	var buf bytes.Buffer
+	for _, deadLetter := range deadLetters {
+		line, err := json.Marshal(deadLetter)
+		if err != nil {
+			return fmt.Errorf("unable to marshal dead letter: %w", err)
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open dead-letter file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("unable to write dead letters: %w", err)
+	}
+
+	return nil
+}
+
+func (f *FileDeadLetterStore) List(ctx context.Context, filter types.DeadLetterFilter) ([]types.DeadLetter, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	all, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := []types.DeadLetter{}
+	for _, deadLetter := range all {
+		if filter.Match(deadLetter) {
+			deadLetters = append(deadLetters, deadLetter)
+		}
+	}
+
+	sortDeadLetters(deadLetters)
+
+	return deadLetters, nil
+}
+
+// Delete rewrites the file without the given dead letters.
+func (f *FileDeadLetterStore) Delete(ctx context.Context, ids []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	deleted := map[string]bool{}
+	for _, id := range ids {
+		deleted[id] = true
+	}
+
+	all, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, deadLetter := range all {
+		if deleted[deadLetter.Id] {
+			continue
+		}
+
+		line, err := json.Marshal(deadLetter)
+		if err != nil {
+			return fmt.Errorf("unable to marshal dead letter: %w", err)
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to rewrite dead-letter file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to rewrite dead-letter file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to rewrite dead-letter file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("unable to rewrite dead-letter file: %w", err)
+	}
+
+	return nil
+}
+
+// read returns the latest dead letter of each id, in the order they were
+// first sent.
+func (f *FileDeadLetterStore) read() ([]types.DeadLetter, error) {
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []types.DeadLetter{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open dead-letter file: %w", err)
+	}
+	defer file.Close()
+
+	deadLetters := []types.DeadLetter{}
+	positions := map[string]int{}
+
+	scanner := bufio.NewScanner(file)
+	// Dead letters carry whole stream records and events.
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		deadLetter := types.DeadLetter{}
+		if err := json.Unmarshal(scanner.Bytes(), &deadLetter); err != nil {
+			return nil, fmt.Errorf("invalid dead letter in %s: %w", f.path, err)
+		}
+
+		if i, ok := positions[deadLetter.Id]; ok {
+			deadLetters[i] = deadLetter
+			continue
+		}
+		positions[deadLetter.Id] = len(deadLetters)
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read dead-letter file: %w", err)
+	}
+
+	return deadLetters, nil
+}
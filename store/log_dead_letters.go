@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// LogDeadLetterSink writes dead letters to the log as JSON lines, prefixed so
+// that they can be found with CloudWatch Logs Insights.
+type LogDeadLetterSink struct {
+	logger *log.Logger
+}
+
+var _ types.DeadLetterSink = (*LogDeadLetterSink)(nil)
+
+func NewLogDeadLetterSink(logger *log.Logger) *LogDeadLetterSink {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return &LogDeadLetterSink{
+		logger: logger,
+	}
+}
+
+func (l *LogDeadLetterSink) Send(ctx context.Context, deadLetters []types.DeadLetter) error {
+	for _, deadLetter := range deadLetters {
+		data, err := json.Marshal(deadLetter)
+		if err != nil {
+			return fmt.Errorf("unable to marshal dead letter: %w", err)
+		}
+
+		l.logger.Printf("DEAD_LETTER %s", data)
+	}
+
+	return nil
+}
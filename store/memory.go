@@ -2,41 +2,73 @@ package store
 
 import (
 	"context"
-
-	"github.com/aws/aws-sdk-go-v2/aws"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/aws-samples/serverless-go-demo/types"
 )
 
 type MemoryStore struct {
+	mu      sync.RWMutex
 	storage map[string]types.Product
+
+	outbox map[string]types.ProductChange
+	now    func() time.Time
 }
 
 // Just to make sure MemoryStore implements the Store interface
-var _ types.Store = (*MemoryStore)(nil)
+var _ types.ConditionalStore = (*MemoryStore)(nil)
+var _ types.VersionedStore = (*MemoryStore)(nil)
+var _ types.Outbox = (*MemoryStore)(nil)
+
+type MemoryStoreOption func(*MemoryStore)
 
-func NewMemoryStore() *MemoryStore {
-	return &MemoryStore{
+// WithOutbox records every change in an outbox, like a DynamoDBStore set up
+// with WithOutboxTable. The store is then its own outbox.
+func WithOutbox() MemoryStoreOption {
+	return func(m *MemoryStore) {
+		m.outbox = make(map[string]types.ProductChange)
+	}
+}
+
+func NewMemoryStore(opts ...MemoryStoreOption) *MemoryStore {
+	m := &MemoryStore{
 		storage: make(map[string]types.Product),
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(m)
 	}
+
+	return m
 }
 
 func (m *MemoryStore) All(ctx context.Context, next *string) (types.ProductRange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Every product fits in the first page, which has no next one.
 	productRange := types.ProductRange{
 		Products: []types.Product{},
-		Next:     aws.String("random next string"),
 	}
 
 	for _, v := range m.storage {
-		productRange.Products = append(productRange.Products, v)
+		if !v.Deleted {
+			productRange.Products = append(productRange.Products, v)
+		}
 	}
 
 	return productRange, nil
 }
 
 func (m *MemoryStore) Get(ctx context.Context, id string) (*types.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	p, ok := m.storage[id]
-	if !ok {
+	if !ok || p.Deleted {
 		return nil, nil
 	}
 
@@ -44,13 +76,140 @@ func (m *MemoryStore) Get(ctx context.Context, id string) (*types.Product, error
 }
 
 func (m *MemoryStore) Put(ctx context.Context, p types.Product) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.recordChange(p.Id, &p); err != nil {
+		return err
+	}
+
 	m.storage[p.Id] = p
 
 	return nil
 }
 
+func (m *MemoryStore) PutIfChanged(ctx context.Context, p types.Product, ignoredFields []string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.storage[p.Id]; ok && types.Unchanged(stored, p, ignoredFields) {
+		return false, nil
+	}
+
+	if err := m.recordChange(p.Id, &p); err != nil {
+		return false, err
+	}
+
+	m.storage[p.Id] = p
+
+	return true, nil
+}
+
 func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.recordChange(id, nil); err != nil {
+		return err
+	}
+
+	delete(m.storage, id)
+
+	return nil
+}
+
+func (m *MemoryStore) PutIfUnmodified(ctx context.Context, p types.Product, updatedAt *time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.storage[p.Id]; !ok || !types.Unmodified(&stored, updatedAt) {
+		return false, nil
+	}
+
+	if err := m.recordChange(p.Id, &p); err != nil {
+		return false, err
+	}
+
+	m.storage[p.Id] = p
+
+	return true, nil
+}
+
+func (m *MemoryStore) DeleteIfUnmodified(ctx context.Context, id string, updatedAt *time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.storage[id]; !ok || !types.Unmodified(&stored, updatedAt) {
+		return false, nil
+	}
+
+	if err := m.recordChange(id, nil); err != nil {
+		return false, err
+	}
+
 	delete(m.storage, id)
 
+	return true, nil
+}
+
+// recordChange adds the change of the product to after to the outbox, when
+// there is one. It must be called with the lock held.
+func (m *MemoryStore) recordChange(id string, after *types.Product) error {
+	if m.outbox == nil {
+		return nil
+	}
+
+	var before *types.Product
+	if p, ok := m.storage[id]; ok {
+		before = &p
+	}
+
+	if before == nil && after == nil {
+		return nil
+	}
+
+	if after != nil {
+		p := *after
+		after = &p
+	}
+
+	change, err := newProductChange(before, after, m.now())
+	if err != nil {
+		return err
+	}
+
+	m.outbox[change.Id] = change
+
+	return nil
+}
+
+func (m *MemoryStore) Pending(ctx context.Context, limit int) ([]types.ProductChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	changes := make([]types.ProductChange, 0, len(m.outbox))
+	for _, change := range m.outbox {
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Id < changes[j].Id
+	})
+
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+
+	return changes, nil
+}
+
+func (m *MemoryStore) Remove(ctx context.Context, ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		delete(m.outbox, id)
+	}
+
 	return nil
 }
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]types.APIKey
+}
+
+var _ types.APIKeyStore = (*MemoryAPIKeyStore)(nil)
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys: make(map[string]types.APIKey),
+	}
+}
+
+func (m *MemoryAPIKeyStore) Get(ctx context.Context, id string) (*types.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &key, nil
+}
+
+func (m *MemoryAPIKeyStore) Put(ctx context.Context, key types.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.keys[key.Id] = key
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]types.IdempotencyRecord
+}
+
+var _ types.IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]types.IdempotencyRecord),
+	}
+}
+
+func (m *MemoryIdempotencyStore) Get(ctx context.Context, key string) (*types.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if record.ExpiresAt <= time.Now().Unix() {
+		delete(m.records, key)
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+func (m *MemoryIdempotencyStore) Reserve(ctx context.Context, record types.IdempotencyRecord) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[record.Key]; ok && existing.ExpiresAt > time.Now().Unix() {
+		return false, nil
+	}
+
+	m.records[record.Key] = record
+
+	return true, nil
+}
+
+func (m *MemoryIdempotencyStore) Put(ctx context.Context, record types.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.records[record.Key]; !ok || existing.Fingerprint != record.Fingerprint {
+		return fmt.Errorf("idempotency key '%s' is no longer reserved for this request", record.Key)
+	}
+
+	m.records[record.Key] = record
+
+	return nil
+}
+
+func (m *MemoryIdempotencyStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.records, key)
+
+	return nil
+}
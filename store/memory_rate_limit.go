@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]types.TokenBucket
+}
+
+var _ types.RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]types.TokenBucket),
+	}
+}
+
+func (m *MemoryRateLimitStore) Get(ctx context.Context, key string) (*types.TokenBucket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return &bucket, nil
+}
+
+func (m *MemoryRateLimitStore) Put(ctx context.Context, bucket types.TokenBucket, expectedVersion int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.buckets[bucket.Key].Version != expectedVersion {
+		return types.ErrBucketConflict
+	}
+
+	m.buckets[bucket.Key] = bucket
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+type MemorySearchIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[string]float64
+}
+
+var _ types.SearchIndex = (*MemorySearchIndex)(nil)
+
+func NewMemorySearchIndex() *MemorySearchIndex {
+	return &MemorySearchIndex{
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+func (m *MemorySearchIndex) Write(ctx context.Context, put []types.SearchPosting, remove []types.SearchPosting) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, posting := range remove {
+		delete(m.postings[posting.Term], posting.Id)
+		if len(m.postings[posting.Term]) == 0 {
+			delete(m.postings, posting.Term)
+		}
+	}
+
+	for _, posting := range put {
+		if m.postings[posting.Term] == nil {
+			m.postings[posting.Term] = make(map[string]float64)
+		}
+		m.postings[posting.Term][posting.Id] = posting.Weight
+	}
+
+	return nil
+}
+
+func (m *MemorySearchIndex) Postings(ctx context.Context, terms []string) ([]types.SearchPosting, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	postings := []types.SearchPosting{}
+	for _, term := range terms {
+		for id, weight := range m.postings[term] {
+			postings = append(postings, types.SearchPosting{Term: term, Id: id, Weight: weight})
+		}
+	}
+
+	return postings, nil
+}
+
+func (m *MemorySearchIndex) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.postings = make(map[string]map[string]float64)
+
+	return nil
+}
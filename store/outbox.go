@@ -0,0 +1,36 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/aws-samples/serverless-go-demo/types"
+)
+
+// newProductChange describes the change from before to after, either of them
+// being nil when the product doesn't exist. Change ids start with the time of
+// the change, so that sorting them sorts the changes.
+func newProductChange(before *types.Product, after *types.Product, now time.Time) (types.ProductChange, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return types.ProductChange{}, fmt.Errorf("unable to generate change id: %w", err)
+	}
+
+	change := types.ProductChange{
+		Id:     fmt.Sprintf("%020d-%s", now.UnixNano(), hex.EncodeToString(random)),
+		Type:   types.ProductUpdated,
+		Time:   now.UTC(),
+		Before: before,
+		After:  after,
+	}
+
+	if before == nil {
+		change.Type = types.ProductCreated
+	} else if after == nil {
+		change.Type = types.ProductDeleted
+	}
+
+	return change, nil
+}
@@ -0,0 +1,28 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// APIKey is the stored part of an API key. The secret itself is never stored,
+// only its SHA-256 hash.
+type APIKey struct {
+	Id        string     `dynamodbav:"id" json:"id"`
+	Owner     string     `dynamodbav:"owner" json:"owner"`
+	Scopes    []string   `dynamodbav:"scopes,stringset" json:"scopes"`
+	Hash      string     `dynamodbav:"hash" json:"-"`
+	CreatedAt time.Time  `dynamodbav:"createdAt" json:"createdAt"`
+	ExpiresAt *time.Time `dynamodbav:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `dynamodbav:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+
+	// After a rotation the previous secret keeps working until PreviousExpiresAt,
+	// giving clients time to switch.
+	PreviousHash      string     `dynamodbav:"previousHash,omitempty" json:"-"`
+	PreviousExpiresAt *time.Time `dynamodbav:"previousExpiresAt,omitempty" json:"-"`
+}
+
+type APIKeyStore interface {
+	Get(context.Context, string) (*APIKey, error)
+	Put(context.Context, APIKey) error
+}
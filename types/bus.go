@@ -7,6 +7,11 @@ type Event struct {
 	Detail     string
 	DetailType string
 	Resources  []string
+
+	// Id is unique to each change, so that consumers can drop duplicates.
+	Id string
+	// Subject is the id of the product the event is about.
+	Subject string
 }
 
 type FailedEvent struct {
@@ -18,3 +23,18 @@ type FailedEvent struct {
 type Bus interface {
 	Put(context.Context, []Event) ([]FailedEvent, error)
 }
+
+// retryableFailureCodes are the entry error codes that may succeed when sent
+// again. Any other code, such as AccessDeniedException or a validation error,
+// is permanent.
+var retryableFailureCodes = map[string]bool{
+	"ThrottlingException": true,
+	"InternalFailure":     true,
+	"InternalException":   true,
+	"ServiceUnavailable":  true,
+}
+
+// IsRetryable tells if an entry that failed with this code may be sent again.
+func IsRetryable(code string) bool {
+	return retryableFailureCodes[code]
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// DeadLetter is a stream record given up on after failing too many times,
+// kept with the reason of its last failure so that it can be inspected and
+// replayed.
+type DeadLetter struct {
+	// Id is the sequence number of the record.
+	Id             string    `dynamodbav:"id" json:"id"`
+	FailureCode    string    `dynamodbav:"failureCode" json:"failureCode"`
+	FailureMessage string    `dynamodbav:"failureMessage" json:"failureMessage"`
+	Attempts       int       `dynamodbav:"attempts" json:"attempts"`
+	FailedAt       time.Time `dynamodbav:"failedAt" json:"failedAt"`
+	// Events are those built from the record that failed to be published.
+	Events []Event `dynamodbav:"events,omitempty" json:"events,omitempty"`
+	// Record is the raw stream record, as JSON.
+	Record json.RawMessage `dynamodbav:"record,omitempty" json:"record,omitempty"`
+	// Truncated tells that Record, and Events if still needed, were left out
+	// because the dead letter was too large for the sink.
+	Truncated bool `dynamodbav:"truncated,omitempty" json:"truncated,omitempty"`
+}
+
+type DeadLetterSink interface {
+	Send(context.Context, []DeadLetter) error
+}
+
+// DeadLetterStore keeps dead letters until they are replayed.
+type DeadLetterStore interface {
+	DeadLetterSink
+	// List returns the dead letters matching the filter, oldest first.
+	List(context.Context, DeadLetterFilter) ([]DeadLetter, error)
+	Delete(context.Context, []string) error
+}
+
+// DeadLetterFilter selects dead letters by failure code and time. Zero
+// fields match any dead letter.
+type DeadLetterFilter struct {
+	FailureCode string
+	Since       time.Time
+	Until       time.Time
+}
+
+func (f DeadLetterFilter) Match(d DeadLetter) bool {
+	if f.FailureCode != "" && d.FailureCode != f.FailureCode {
+		return false
+	}
+
+	if !f.Since.IsZero() && d.FailedAt.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && !d.FailedAt.Before(f.Until) {
+		return false
+	}
+
+	return true
+}
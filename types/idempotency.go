@@ -0,0 +1,25 @@
+package types
+
+import "context"
+
+type IdempotencyRecord struct {
+	Key         string `dynamodbav:"key"`
+	Fingerprint string `dynamodbav:"fingerprint"`
+	// Pending marks a key whose request is still being handled.
+	Pending         bool              `dynamodbav:"pending,omitempty"`
+	StatusCode      int               `dynamodbav:"statusCode"`
+	Headers         map[string]string `dynamodbav:"headers"`
+	Body            string            `dynamodbav:"body"`
+	IsBase64Encoded bool              `dynamodbav:"isBase64Encoded"`
+	ExpiresAt       int64             `dynamodbav:"expiresAt"`
+}
+
+type IdempotencyStore interface {
+	Get(context.Context, string) (*IdempotencyRecord, error)
+	// Reserve stores a record unless an unexpired one already exists for its
+	// key, and reports whether it did.
+	Reserve(context.Context, IdempotencyRecord) (bool, error)
+	// Put stores a record, replacing the reservation of its key.
+	Put(context.Context, IdempotencyRecord) error
+	Delete(context.Context, string) error
+}
@@ -0,0 +1,12 @@
+package types
+
+import "context"
+
+// Outbox holds the product changes written in the same transaction as the
+// products, until they are published.
+type Outbox interface {
+	// Pending returns up to limit changes, oldest first.
+	Pending(ctx context.Context, limit int) ([]ProductChange, error)
+	// Remove forgets published changes by id.
+	Remove(ctx context.Context, ids []string) error
+}
@@ -1,9 +1,25 @@
 package types
 
+import "time"
+
 type Product struct {
 	Id    string  `dynamodbav:"id" json:"id"`
 	Name  string  `dynamodbav:"name" json:"name"`
 	Price float64 `dynamodbav:"price" json:"price"`
+
+	// Attributes holds supplier specific data, such as costs and contract
+	// references. Some of them can be encrypted at rest by store.EncryptedStore.
+	Attributes map[string]string `dynamodbav:"attributes,omitempty" json:"attributes,omitempty" description:"Supplier specific attributes"`
+
+	// Tags are free-form labels products can be searched by.
+	Tags []string `dynamodbav:"tags,omitempty" json:"tags,omitempty" description:"Labels the product can be searched by"`
+
+	UpdatedBy string     `dynamodbav:"updatedBy,omitempty" json:"updatedBy,omitempty" readOnly:"true" description:"Subject of the principal that made the last change"`
+	UpdatedAt *time.Time `dynamodbav:"updatedAt,omitempty" json:"updatedAt,omitempty" readOnly:"true" description:"Time of the last change"`
+
+	// Deleted marks a tombstone, written just before an item is deleted so that
+	// the stream REMOVE record carries who deleted it. Stores hide tombstones.
+	Deleted bool `dynamodbav:"deleted,omitempty" json:"-"`
 }
 
 type ProductRange struct {
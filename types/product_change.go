@@ -0,0 +1,43 @@
+package types
+
+import "time"
+
+type ChangeType string
+
+const (
+	ProductCreated ChangeType = "created"
+	ProductUpdated ChangeType = "updated"
+	ProductDeleted ChangeType = "deleted"
+)
+
+// ProductChange is a change to a product as read from the table stream or the
+// outbox. Before is nil for creations and After is nil for deletions.
+type ProductChange struct {
+	// Id is unique to each change.
+	Id     string     `dynamodbav:"id"`
+	Type   ChangeType `dynamodbav:"type"`
+	Time   time.Time  `dynamodbav:"time"`
+	Before *Product   `dynamodbav:"before,omitempty"`
+	After  *Product   `dynamodbav:"after,omitempty"`
+}
+
+// ProductChangedDetail is the data of ProductChanged events.
+type ProductChangedDetail struct {
+	Before        Product  `json:"before"`
+	After         Product  `json:"after"`
+	ChangedFields []string `json:"changedFields"`
+}
+
+// PriceChangedDetail is the data of PriceChanged events.
+type PriceChangedDetail struct {
+	Id   string  `json:"id"`
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+// ProductRenamedDetail is the data of ProductRenamed events.
+type ProductRenamedDetail struct {
+	Id   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
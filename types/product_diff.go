@@ -0,0 +1,73 @@
+package types
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultIgnoredFields are the product fields that don't make a change on
+// their own, as they are stamped on every write.
+var DefaultIgnoredFields = []string{"updatedAt", "updatedBy"}
+
+// ChangedFields lists the JSON names of the product fields that differ.
+func ChangedFields(before Product, after Product) []string {
+	fields := []string{}
+
+	beforeValue := reflect.ValueOf(before)
+	afterValue := reflect.ValueOf(after)
+	productType := beforeValue.Type()
+
+	for i := 0; i < productType.NumField(); i++ {
+		name := strings.Split(productType.Field(i).Tag.Get("json"), ",")[0]
+		if name == "-" || name == "" {
+			continue
+		}
+
+		if !reflect.DeepEqual(beforeValue.Field(i).Interface(), afterValue.Field(i).Interface()) {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+// Unchanged tells whether before and after only differ by ignored fields.
+// Tombstones are never the same as live products.
+func Unchanged(before Product, after Product, ignoredFields []string) bool {
+	if before.Deleted != after.Deleted {
+		return false
+	}
+
+	for _, field := range ChangedFields(before, after) {
+		if !contains(ignoredFields, field) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unmodified tells whether the stored product was last updated at updatedAt,
+// nil standing for a product that doesn't record it.
+func Unmodified(stored *Product, updatedAt *time.Time) bool {
+	if stored == nil {
+		return false
+	}
+
+	if stored.UpdatedAt == nil || updatedAt == nil {
+		return stored.UpdatedAt == nil && updatedAt == nil
+	}
+
+	return stored.UpdatedAt.Equal(*updatedAt)
+}
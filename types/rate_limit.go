@@ -0,0 +1,32 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrBucketConflict = errors.New("token bucket was updated concurrently")
+
+// RateLimit allows Requests per Period on average, with bursts of up to Burst
+// requests.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+type TokenBucket struct {
+	Key       string  `dynamodbav:"key"`
+	Tokens    float64 `dynamodbav:"tokens"`
+	UpdatedAt int64   `dynamodbav:"updatedAt"`
+	Version   int64   `dynamodbav:"version"`
+	ExpiresAt int64   `dynamodbav:"expiresAt"`
+}
+
+type RateLimitStore interface {
+	Get(context.Context, string) (*TokenBucket, error)
+	// Put stores the bucket only if the stored one still has the given version,
+	// returning ErrBucketConflict otherwise. Version 0 means it must not exist.
+	Put(context.Context, TokenBucket, int64) error
+}
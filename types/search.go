@@ -0,0 +1,23 @@
+package types
+
+import "context"
+
+// SearchPosting records that a search term occurs in a product, with the
+// weight it has there.
+type SearchPosting struct {
+	Term   string  `dynamodbav:"term"`
+	Id     string  `dynamodbav:"id"`
+	Weight float64 `dynamodbav:"weight"`
+}
+
+// SearchIndex is an inverted index from search terms to the products they
+// occur in, maintained from the product changes.
+type SearchIndex interface {
+	// Write adds or replaces the postings in put, and removes those in
+	// remove, which only need their term and id.
+	Write(ctx context.Context, put []SearchPosting, remove []SearchPosting) error
+	// Postings returns the postings of the terms.
+	Postings(ctx context.Context, terms []string) ([]SearchPosting, error)
+	// Clear removes every posting.
+	Clear(ctx context.Context) error
+}
@@ -4,6 +4,7 @@ package types
 
 import (
 	"context"
+	"time"
 )
 
 type Store interface {
@@ -12,3 +13,28 @@ type Store interface {
 	Put(context.Context, Product) error
 	Delete(context.Context, string) error
 }
+
+// ConditionalStore is a Store that can skip writes that wouldn't change the
+// stored product.
+type ConditionalStore interface {
+	Store
+
+	// PutIfChanged puts the product unless the stored one only differs by
+	// the ignored fields, and tells whether it was written.
+	PutIfChanged(ctx context.Context, product Product, ignoredFields []string) (bool, error)
+}
+
+// VersionedStore is a Store that can write a product on the condition that it
+// wasn't changed since it was read, as told by its updatedAt.
+type VersionedStore interface {
+	Store
+
+	// PutIfUnmodified puts the product if the stored one, tombstones
+	// included, was last updated at updatedAt, nil standing for a product
+	// that doesn't record it. It tells whether the product was written.
+	PutIfUnmodified(ctx context.Context, product Product, updatedAt *time.Time) (bool, error)
+
+	// DeleteIfUnmodified deletes the product on the same condition, and
+	// tells whether it was deleted.
+	DeleteIfUnmodified(ctx context.Context, id string, updatedAt *time.Time) (bool, error)
+}